@@ -0,0 +1,60 @@
+package ast
+
+import "testing"
+
+func TestArenaNewBinaryNodeMatchesPackageConstructor(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	arena := NewArena()
+
+	left := arena.NewZvalNode(int64(1), pos)
+	right := arena.NewZvalNode(int64(2), pos)
+	bin := arena.NewBinaryNode(AST_BINARY_OP, "+", left, right, pos)
+
+	if bin.GetKind() != AST_BINARY_OP || bin.Operator != "+" {
+		t.Errorf("unexpected arena-allocated node: %#v", bin)
+	}
+	if len(bin.GetChildren()) != 2 {
+		t.Errorf("expected 2 children, got %d", len(bin.GetChildren()))
+	}
+}
+
+func TestArenaSlabSurvivesGrowthPastBlockSize(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	arena := NewArena()
+
+	var nodes []*ZvalNode
+	for i := 0; i < arenaBlockSize*3+5; i++ {
+		nodes = append(nodes, arena.NewZvalNode(int64(i), pos))
+	}
+
+	for i, n := range nodes {
+		if n.Value.(int64) != int64(i) {
+			t.Fatalf("node %d: expected value %d, got %v (a pointer returned earlier was invalidated by slab growth)", i, i, n.Value)
+		}
+	}
+}
+
+func TestStringTableInternsDuplicateStrings(t *testing.T) {
+	var table StringTable
+
+	a := table.Intern("foo")
+	b := table.Intern("foo")
+	if a != b {
+		t.Errorf("expected interned strings to be equal, got %q and %q", a, b)
+	}
+	if len(table.interned) != 1 {
+		t.Errorf("expected exactly 1 entry in the intern table, got %d", len(table.interned))
+	}
+}
+
+func TestArenaFreeDropsReferences(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	arena := NewArena()
+	arena.NewZvalNode(int64(1), pos)
+
+	arena.Free()
+
+	if len(arena.zvals.blocks) != 0 {
+		t.Errorf("expected Free to drop all slab blocks, got %d blocks", len(arena.zvals.blocks))
+	}
+}
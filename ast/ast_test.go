@@ -17,9 +17,13 @@ func TestASTKind(t *testing.T) {
 		isStatement  bool
 	}{
 		{AST_ZVAL, "AST_ZVAL", -1, true, false, false, false},
-		{AST_CONSTANT, "AST_CONSTANT", -1, true, false, false, false},
+		{AST_CONSTANT, "AST_CONSTANT", 0, false, false, true, false},
+		{AST_CALLABLE_CONVERT, "AST_CALLABLE_CONVERT", 0, false, false, true, false},
+		{AST_IDENTIFIER, "AST_IDENTIFIER", 0, false, false, true, false},
 		{AST_CLOSURE, "AST_CLOSURE", 5, true, false, false, false},
 		{AST_STMT_LIST, "AST_STMT_LIST", -1, false, true, false, true},
+		{AST_LIST, "AST_LIST", -1, false, true, false, false},
+		{AST_NAME, "AST_NAME", 0, false, false, true, false},
 		{AST_VAR, "AST_VAR", 1, false, false, true, false},
 		{AST_BINARY_OP, "AST_BINARY_OP", 2, false, false, true, false},
 		{AST_METHOD_CALL, "AST_METHOD_CALL", 3, false, false, true, false},
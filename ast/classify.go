@@ -0,0 +1,64 @@
+package ast
+
+// Expression、Statement、Declaration、Lvalue是四个标记接口，给常见
+// 的节点语义类别一个编译期可检查的身份。和IsExpression()/
+// IsStatement()等(kind.go里基于ASTKind数值区间的运行期判断，参见
+// isExpressionSlow等)不同，这里要求具体的node struct显式声明自己
+// 属于哪个类别——配合As[T]/FindAll[T]可以写"把树里所有表达式捞出来"
+// 这样类型安全的代码，不需要先断言成Node、再调用GetKind().IsExpression()
+// 自己再转型一次。
+//
+// 两套机制并存是有意的：ASTKind那一套描述的是"php-ast意义上的
+// 分类"，覆盖了parser还没有产出typed节点的那些kind；这里这一套描述
+// 的是"ast包目前已有的typed node struct分别属于哪个类别"，只覆盖
+// 已经有struct的那部分。
+
+// Expression 标记表达式节点
+type Expression interface {
+	Node
+	expressionNode()
+}
+
+// Statement 标记语句节点
+type Statement interface {
+	Node
+	statementNode()
+}
+
+// Declaration 标记函数/类/方法等声明节点。parser目前还没有产出专门
+// 的typed声明节点(FuncDeclNode/ClassNode等仍然只是裸的ASTKind)，
+// 所以这个接口暂时没有实现者——FindAll[Declaration]不会报错，只是
+// 拿到空切片，等parser补上对应类型后再把它们标记为Declaration
+type Declaration interface {
+	Node
+	declarationNode()
+}
+
+// Lvalue 标记可以合法出现在赋值左侧(或者解构赋值目标位置)的节点：
+// 变量、属性访问、数组下标访问，以及list()/[...]形式的解构目标
+type Lvalue interface {
+	Node
+	lvalueNode()
+}
+
+func (*VariableNode) expressionNode()    {}
+func (*IdentifierNode) expressionNode()  {}
+func (*ConstantNode) expressionNode()    {}
+func (*ZvalNode) expressionNode()        {}
+func (*UnaryNode) expressionNode()       {}
+func (*BinaryNode) expressionNode()      {}
+func (*AssignNode) expressionNode()      {}
+func (*TernaryNode) expressionNode()     {}
+func (*CallNode) expressionNode()        {}
+func (*MethodCallNode) expressionNode()  {}
+func (*PropertyNode) expressionNode()    {}
+func (*ArrayAccessNode) expressionNode() {}
+
+func (*IfNode) statementNode()    {}
+func (*WhileNode) statementNode() {}
+func (*ForNode) statementNode()   {}
+
+func (*VariableNode) lvalueNode()     {}
+func (*PropertyNode) lvalueNode()     {}
+func (*ArrayAccessNode) lvalueNode()  {}
+func (*ListNode) lvalueNode()         {}
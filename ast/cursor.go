@@ -0,0 +1,174 @@
+package ast
+
+// VisitorEnterLeave 是一个比包里已有的单方法Visitor(BaseNode.Accept
+// 使用)和WalkVisitor(Traverse使用，Enter需要自己决定用哪个visitor
+// 继续遍历)更轻量的Enter/Leave访问者：Enter只需要返回"要不要深入
+// 子节点"的bool。搭配InspectVisitor使用，不需要关心Cursor。
+type VisitorEnterLeave interface {
+	Enter(n Node) (recurse bool)
+	Leave(n Node)
+}
+
+// Cursor 描述Inspect遍历过程中的"当前位置"：当前节点、它的父节点、
+// 从根到当前节点的完整路径，以及它在父节点子节点序列中的下标。
+// 建模自Go工具链里astutil.Apply的Cursor，额外加了Path()——
+// astutil.Cursor没有这个方法，但很多需要"往上看好几层"的pass(比如
+// 判断是否处于循环体内)用得到。
+//
+// Replace/Delete/InsertBefore/InsertAfter都是对父节点的就地修改：
+// 当父节点是*ListNode时可以随意增删；其余固定元数的节点类型只能
+// Replace(复用setChild)，Delete/InsertBefore/InsertAfter在这种
+// 父节点上是no-op，因为"从一个固定几个字段的struct里删掉一个字段"
+// 没有意义。
+type Cursor struct {
+	node    Node
+	parent  Node
+	path    []Node
+	index   int
+	leaving bool
+	deleted bool
+}
+
+// Node 返回当前节点
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent 返回当前节点的父节点，根节点为nil
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Path 返回从根节点到当前节点(含)的祖先链
+func (c *Cursor) Path() []Node {
+	path := make([]Node, len(c.path))
+	copy(path, c.path)
+	return path
+}
+
+// Index 返回当前节点在父节点GetChildren()中的下标，根节点为-1
+func (c *Cursor) Index() int { return c.index }
+
+// Leaving 为true表示这次回调是节点"离开"时触发的(子节点已遍历完)，
+// 为false表示是"进入"节点时触发的
+func (c *Cursor) Leaving() bool { return c.leaving }
+
+// Replace 把当前节点替换成n。对*ListNode父节点直接改写对应下标的
+// Elements；其余父节点类型复用setChild，其覆盖范围和ast.Rewriter
+// 相同
+func (c *Cursor) Replace(n Node) {
+	if c.parent != nil && c.index >= 0 {
+		if list, ok := c.parent.(*ListNode); ok {
+			if c.index < len(list.Elements) {
+				list.Elements[c.index] = n
+				list.Children = list.Elements
+			}
+		} else {
+			setChild(c.parent, c.index, n)
+		}
+	}
+	c.node = n
+}
+
+// Delete 把当前节点从父节点的子节点列表中移除。只在父节点是
+// *ListNode时生效
+func (c *Cursor) Delete() {
+	list, ok := c.parent.(*ListNode)
+	if !ok || c.index < 0 || c.index >= len(list.Elements) {
+		return
+	}
+	list.Elements = append(list.Elements[:c.index], list.Elements[c.index+1:]...)
+	list.Children = list.Elements
+	c.deleted = true
+}
+
+// InsertBefore 在当前节点之前插入n。只在父节点是*ListNode时生效
+func (c *Cursor) InsertBefore(n Node) {
+	c.insertAt(c.index, n)
+}
+
+// InsertAfter 在当前节点之后插入n。只在父节点是*ListNode时生效
+func (c *Cursor) InsertAfter(n Node) {
+	c.insertAt(c.index+1, n)
+}
+
+func (c *Cursor) insertAt(idx int, n Node) {
+	list, ok := c.parent.(*ListNode)
+	if !ok || idx < 0 {
+		return
+	}
+	list.Elements = append(list.Elements, nil)
+	copy(list.Elements[idx+1:], list.Elements[idx:])
+	list.Elements[idx] = n
+	list.Children = list.Elements
+	if idx <= c.index {
+		c.index++
+	}
+}
+
+// Inspect以深度优先顺序遍历root，对每个节点的"进入"和"离开"各调用
+// 一次fn(通过Cursor.Leaving()区分)。fn在进入时返回false会跳过该
+// 节点的子树(不深入、也不会有对应的离开回调)。遍历过程中调用
+// Cursor上的Replace/Delete/InsertBefore/InsertAfter可以就地修改
+// 树，这是它和ast.Traverse/ast.WalkWithParent的主要区别——后两者
+// 只读不写(Traverse本身)或者只支持整节点替换(WalkWithParent)。
+func Inspect(root Node, fn func(*Cursor) bool) {
+	inspect(root, nil, nil, -1, fn)
+}
+
+func inspect(n Node, parent Node, path []Node, index int, fn func(*Cursor) bool) {
+	if n == nil {
+		return
+	}
+
+	nodePath := make([]Node, len(path), len(path)+1)
+	copy(nodePath, path)
+	nodePath = append(nodePath, n)
+
+	enter := &Cursor{node: n, parent: parent, path: nodePath, index: index}
+	if !fn(enter) {
+		return
+	}
+	if enter.deleted {
+		return
+	}
+
+	inspectChildren(enter.node, nodePath, fn)
+
+	fn(&Cursor{node: enter.node, parent: parent, path: nodePath, index: index, leaving: true})
+}
+
+// inspectChildren深入n的子节点。对*ListNode特殊处理：每一步都重新
+// 读取list.Elements的当前长度，而不是像固定元数节点那样对
+// GetChildren()做一次性的range——后者在循环过程中对底层数组做的
+// Delete/InsertBefore/InsertAfter不会反映到一个已经固定下来的
+// range快照里，会导致游标和实际下标对不上
+func inspectChildren(n Node, path []Node, fn func(*Cursor) bool) {
+	if list, ok := n.(*ListNode); ok {
+		i := 0
+		for i < len(list.Elements) {
+			before := len(list.Elements)
+			inspect(list.Elements[i], n, path, i, fn)
+			after := len(list.Elements)
+			if after < before {
+				// 当前元素把自己删掉了，后面的元素已经前移到i这个
+				// 位置，游标不需要前进
+				continue
+			}
+			i += 1 + (after - before)
+		}
+		return
+	}
+
+	for i, child := range n.GetChildren() {
+		inspect(child, n, path, i, fn)
+	}
+}
+
+// InspectVisitor用VisitorEnterLeave的语义跑一遍Inspect，调用方不需
+// 要关心Cursor，只需要实现Enter/Leave
+func InspectVisitor(root Node, v VisitorEnterLeave) {
+	Inspect(root, func(c *Cursor) bool {
+		if c.Leaving() {
+			v.Leave(c.Node())
+			return true
+		}
+		return v.Enter(c.Node())
+	})
+}
@@ -0,0 +1,113 @@
+package ast
+
+import "testing"
+
+func TestInspectVisitsEnterAndLeave(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	left := NewIntegerLiteral(1, pos)
+	right := NewIntegerLiteral(2, pos)
+	binary := NewBinaryNode(AST_BINARY_OP, "+", left, right, pos)
+
+	var events []string
+	Inspect(binary, func(c *Cursor) bool {
+		if c.Leaving() {
+			events = append(events, "leave:"+c.Node().GetKind().String())
+		} else {
+			events = append(events, "enter:"+c.Node().GetKind().String())
+		}
+		return true
+	})
+
+	want := []string{
+		"enter:AST_BINARY_OP",
+		"enter:AST_ZVAL", "leave:AST_ZVAL",
+		"enter:AST_ZVAL", "leave:AST_ZVAL",
+		"leave:AST_BINARY_OP",
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %v", len(want), len(events), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], events[i])
+		}
+	}
+}
+
+func TestCursorReplaceInList(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	list := NewStatementList([]Node{NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos)})
+
+	Inspect(list, func(c *Cursor) bool {
+		if !c.Leaving() {
+			if z, ok := c.Node().(*ZvalNode); ok && z.Value == int64(1) {
+				c.Replace(NewIntegerLiteral(100, pos))
+			}
+		}
+		return true
+	})
+
+	if v := list.Elements[0].(*ZvalNode).Value; v != int64(100) {
+		t.Errorf("expected first element to be replaced with 100, got %v", v)
+	}
+}
+
+func TestCursorDeleteAndInsert(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	list := NewStatementList([]Node{NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos), NewIntegerLiteral(3, pos)})
+
+	Inspect(list, func(c *Cursor) bool {
+		if !c.Leaving() {
+			if z, ok := c.Node().(*ZvalNode); ok {
+				if z.Value == int64(2) {
+					c.Delete()
+				}
+				if z.Value == int64(3) {
+					c.InsertBefore(NewIntegerLiteral(99, pos))
+				}
+			}
+		}
+		return true
+	})
+
+	var values []int64
+	for _, el := range list.Elements {
+		values = append(values, el.(*ZvalNode).Value.(int64))
+	}
+	want := []int64{1, 99, 3}
+	if len(values) != len(want) {
+		t.Fatalf("expected %v, got %v", want, values)
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, values)
+			break
+		}
+	}
+}
+
+func TestInspectVisitorEnterLeave(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	binary := NewBinaryNode(AST_BINARY_OP, "+", NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos), pos)
+
+	entered, left := 0, 0
+	InspectVisitor(binary, &countingVisitor{entered: &entered, left: &left})
+
+	if entered != 3 || left != 3 {
+		t.Errorf("expected 3 enters and 3 leaves, got entered=%d left=%d", entered, left)
+	}
+}
+
+type countingVisitor struct {
+	entered *int
+	left    *int
+}
+
+func (v *countingVisitor) Enter(Node) bool {
+	*v.entered++
+	return true
+}
+
+func (v *countingVisitor) Leave(Node) {
+	*v.left++
+}
@@ -0,0 +1,24 @@
+package ast
+
+// As尝试把n断言为具体类型T(通常是某个*XxxNode，或者classify.go里
+// 的一个标记接口)。相比调用方自己写n.(*ast.BinaryNode)，As[T]在泛型
+// 调用处就把T写清楚了，FindAll底下也是复用的这同一个断言
+func As[T Node](n Node) (T, bool) {
+	t, ok := n.(T)
+	return t, ok
+}
+
+// FindAll遍历以root为根的树(含root自身)，按Walk的先序收集所有能
+// 断言为T的节点。T可以是具体的node struct指针类型(*ast.CallNode)，
+// 也可以是classify.go里的标记接口(ast.Expression)——用来写"这棵树
+// 里所有的函数调用"或者"这棵树里所有的表达式"这类类型安全的查询，
+// 不需要调用方自己做类型断言和nil检查
+func FindAll[T Node](root Node) []T {
+	var out []T
+	Walk(root, func(n Node) {
+		if t, ok := As[T](n); ok {
+			out = append(out, t)
+		}
+	})
+	return out
+}
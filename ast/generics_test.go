@@ -0,0 +1,43 @@
+package ast
+
+import "testing"
+
+func TestAsNarrowsToConcreteType(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	var n Node = NewIdentifier("foo", pos)
+
+	ident, ok := As[*IdentifierNode](n)
+	if !ok || ident.Name != "foo" {
+		t.Fatalf("expected As to narrow to *IdentifierNode{Name: foo}, got %#v, %v", ident, ok)
+	}
+
+	if _, ok := As[*ConstantNode](n); ok {
+		t.Errorf("expected As[*ConstantNode] to fail for an IdentifierNode")
+	}
+}
+
+func TestFindAllCollectsByConcreteType(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	left := NewIntegerLiteral(1, pos)
+	right := NewIntegerLiteral(2, pos)
+	bin := NewBinaryNode(AST_BINARY_OP, "+", left, right, pos)
+
+	zvals := FindAll[*ZvalNode](bin)
+	if len(zvals) != 2 {
+		t.Fatalf("expected 2 ZvalNode leaves, got %d", len(zvals))
+	}
+}
+
+func TestFindAllCollectsByMarkerInterface(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	call := NewCallNode(NewIdentifier("strlen", pos), []Node{NewStringLiteral("hi", pos)}, pos)
+	assign := NewAssignNode(NewVariable("n", pos), call, pos)
+
+	exprs := FindAll[Expression](assign)
+	// assign, the variable, the variable's own name zval (NewVariable
+	// wraps the name in a ZvalNode), the call, the identifier callee,
+	// and the string literal zval are all marked Expression.
+	if len(exprs) != 6 {
+		t.Fatalf("expected 6 Expression nodes, got %d: %v", len(exprs), exprs)
+	}
+}
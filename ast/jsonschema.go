@@ -0,0 +1,503 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// currentSchemaVersion是MarshalNode产出的envelope里的schema_version
+// 字段值。和BaseNode.MarshalJSON(只把Kind从数字渲染成可读字符串，
+// 会丢失Left/Right等具体字段，只够TestJSONSerialization那种抽查用)
+// 不同，MarshalNode/UnmarshalNode这一对是为了真正做到"序列化之后能
+// 原样载回来"：每种具体node类型自己的字段都按名字编码，嵌套的Node/
+// []Node字段递归走同一套编码，解码时根据kind在nodeRegistry里查到
+// 对应的Go类型再逐字段填回去。
+//
+// 升级这套格式(加字段、改变某个字段的表示方式)时把这个常量加一，
+// UnmarshalNode按schema_version分支处理，旧版本产出的JSON仍然能被
+// 新代码正确载入——这是"schema versioning"在这里的含义，而不是要求
+// 同时维护多条完全独立的解析路径。
+const currentSchemaVersion = "1"
+
+// RegisterNodeKind让下游包可以把自己的node类型接入UnmarshalNode：
+// kind是这个类型在JSON里出现的kind判别值，factory返回一个"空"的
+// 该类型实例，decodeNode随后把JSON字段解码进去。ast包自己用到的全部
+// 类型已经在init()里注册过，调用方只需要为parser还没有覆盖、但自己
+// 扩展出来的新node类型调用这个函数。
+func RegisterNodeKind(kind ASTKind, factory func() Node) {
+	nodeRegistry[kind] = factory
+}
+
+var nodeRegistry = map[ASTKind]func() Node{}
+
+func init() {
+	nodeRegistry[AST_ZVAL] = func() Node { return &ZvalNode{} }
+	nodeRegistry[AST_CONSTANT] = func() Node { return &ConstantNode{} }
+	nodeRegistry[AST_IDENTIFIER] = func() Node { return &IdentifierNode{} }
+	nodeRegistry[AST_VAR] = func() Node { return &VariableNode{} }
+	nodeRegistry[AST_ASSIGN] = func() Node { return &AssignNode{} }
+	nodeRegistry[AST_ASSIGN_OP] = func() Node { return &AssignNode{} }
+	nodeRegistry[AST_CONDITIONAL] = func() Node { return &TernaryNode{} }
+	nodeRegistry[AST_CALL] = func() Node { return &CallNode{} }
+	nodeRegistry[AST_METHOD_CALL] = func() Node { return &MethodCallNode{} }
+	nodeRegistry[AST_NULLSAFE_METHOD_CALL] = func() Node { return &MethodCallNode{} }
+	nodeRegistry[AST_PROP] = func() Node { return &PropertyNode{} }
+	nodeRegistry[AST_NULLSAFE_PROP] = func() Node { return &PropertyNode{} }
+	nodeRegistry[AST_DIM] = func() Node { return &ArrayAccessNode{} }
+	nodeRegistry[AST_IF_ELEM] = func() Node { return &IfElementNode{} }
+	nodeRegistry[AST_WHILE] = func() Node { return &WhileNode{} }
+	nodeRegistry[AST_FOR] = func() Node { return &ForNode{} }
+
+	// 列表区间(128-147)和跳号的AST_LIST(255)全部是ListNode，见
+	// isListSlow。AST_IF(133)落在这个区间内，所以它的专门注册必须放
+	// 在这个循环之后，否则会被这里的ListNode工厂覆盖掉
+	for k := ASTKind(128); k <= 147; k++ {
+		nodeRegistry[k] = func() Node { return &ListNode{} }
+	}
+	nodeRegistry[AST_LIST] = func() Node { return &ListNode{} }
+	nodeRegistry[AST_IF] = func() Node { return &IfNode{} }
+
+	// 1个子节点的表达式(320-350)默认是UnaryNode，AST_VAR(320)例外，
+	// 用专门的VariableNode(上面已经注册，这里的循环不会覆盖它，因为
+	// 循环按从小到大的顺序写入map、后面对AST_VAR的显式赋值在循环
+	// 之后执行)
+	for k := ASTKind(320); k <= 350; k++ {
+		nodeRegistry[k] = func() Node { return &UnaryNode{} }
+	}
+	nodeRegistry[AST_VAR] = func() Node { return &VariableNode{} }
+
+	// 2个子节点的表达式(384-412)默认是BinaryNode，已经有专门类型的
+	// 几个(AST_DIM/AST_PROP/AST_NULLSAFE_PROP/AST_CALL/AST_ASSIGN/
+	// AST_ASSIGN_OP/AST_WHILE/AST_IF_ELEM)在循环之后重新赋值覆盖掉
+	for k := ASTKind(384); k <= 412; k++ {
+		nodeRegistry[k] = func() Node { return &BinaryNode{} }
+	}
+	nodeRegistry[AST_DIM] = func() Node { return &ArrayAccessNode{} }
+	nodeRegistry[AST_PROP] = func() Node { return &PropertyNode{} }
+	nodeRegistry[AST_NULLSAFE_PROP] = func() Node { return &PropertyNode{} }
+	nodeRegistry[AST_CALL] = func() Node { return &CallNode{} }
+	nodeRegistry[AST_ASSIGN] = func() Node { return &AssignNode{} }
+	nodeRegistry[AST_ASSIGN_OP] = func() Node { return &AssignNode{} }
+	nodeRegistry[AST_WHILE] = func() Node { return &WhileNode{} }
+	nodeRegistry[AST_IF_ELEM] = func() Node { return &IfElementNode{} }
+}
+
+// MarshalNode把n编码成{"schema_version":"1","root":...}这样的顶层
+// envelope。root内部每个node都用它自己具名的字段编码(operator/
+// left/right/...)，而不是phpast.go那种通用的{kind,flags,lineno,
+// children}形状——这里要保留的是"能不能原样载回同一棵typed树"，不是
+// 和真实php-ast扩展的兼容性(那是phpast.go的目标)。
+//
+// 输出用map[string]interface{}承载字段，json.Marshal对
+// map[string]interface{}按key的字典序排序，天然得到"canonical"、
+// 跨两次调用稳定的字段顺序；子节点数组的顺序就是Elements/Arguments
+// 切片本身的顺序，不做任何重排。
+func MarshalNode(n Node) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"root":           encodeNode(n),
+	})
+}
+
+func encodeNode(n Node) interface{} {
+	if n == nil {
+		return nil
+	}
+
+	out := map[string]interface{}{
+		"kind":     int(n.GetKind()),
+		"position": encodePosition(n.GetPosition()),
+	}
+
+	switch node := n.(type) {
+	case *ZvalNode:
+		out["value"] = node.Value
+	case *ConstantNode:
+		out["name"] = node.Name
+	case *IdentifierNode:
+		out["name"] = node.Name
+	case *VariableNode:
+		out["name"] = encodeNode(node.Name)
+	case *UnaryNode:
+		out["operator"] = node.Operator
+		out["operand"] = encodeNode(node.Operand)
+	case *BinaryNode:
+		out["operator"] = node.Operator
+		out["left"] = encodeNode(node.Left)
+		out["right"] = encodeNode(node.Right)
+	case *AssignNode:
+		out["left"] = encodeNode(node.Left)
+		out["right"] = encodeNode(node.Right)
+		if node.Op != "" {
+			out["operator"] = node.Op
+		}
+	case *TernaryNode:
+		out["condition"] = encodeNode(node.Condition)
+		if node.TrueExpr != nil {
+			out["true_expr"] = encodeNode(node.TrueExpr)
+		}
+		out["false_expr"] = encodeNode(node.FalseExpr)
+	case *CallNode:
+		out["callee"] = encodeNode(node.Callee)
+		out["arguments"] = encodeNodeList(node.Arguments)
+	case *MethodCallNode:
+		out["object"] = encodeNode(node.Object)
+		out["method"] = encodeNode(node.Method)
+		out["arguments"] = encodeNodeList(node.Arguments)
+		out["nullsafe"] = node.Nullsafe
+	case *PropertyNode:
+		out["object"] = encodeNode(node.Object)
+		out["property"] = encodeNode(node.Property)
+		out["nullsafe"] = node.Nullsafe
+	case *ArrayAccessNode:
+		out["array"] = encodeNode(node.Array)
+		out["index"] = encodeNode(node.Index)
+	case *ListNode:
+		out["elements"] = encodeNodeList(node.Elements)
+	case *IfNode:
+		out["elements"] = encodeNodeList(node.Elements)
+	case *IfElementNode:
+		if node.Condition != nil {
+			out["condition"] = encodeNode(node.Condition)
+		}
+		out["body"] = encodeNode(node.Body)
+	case *WhileNode:
+		out["condition"] = encodeNode(node.Condition)
+		out["body"] = encodeNode(node.Body)
+	case *ForNode:
+		out["init"] = encodeNode(node.Init)
+		out["condition"] = encodeNode(node.Condition)
+		out["update"] = encodeNode(node.Update)
+		out["body"] = encodeNode(node.Body)
+	}
+
+	return out
+}
+
+func encodeNodeList(nodes []Node) []interface{} {
+	out := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		out[i] = encodeNode(n)
+	}
+	return out
+}
+
+// UnmarshalNode解码MarshalNode产出的envelope，按kind判别值在
+// nodeRegistry里找到对应的Go类型，逐字段把子节点递归解码回typed
+// Node。目前只认识schema_version="1"；版本号不认识时返回明确的
+// 错误而不是静默地按当前格式硬解，这样调用方能分辨"这是一份更老/
+// 更新、需要迁移的存档"和"这就是一份损坏的JSON"。
+func UnmarshalNode(data []byte) (Node, error) {
+	var envelope struct {
+		SchemaVersion string          `json:"schema_version"`
+		Root          json.RawMessage `json:"root"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("astjson: invalid envelope: %w", err)
+	}
+	if envelope.SchemaVersion != currentSchemaVersion {
+		return nil, fmt.Errorf("astjson: unsupported schema_version %q (want %q)", envelope.SchemaVersion, currentSchemaVersion)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(envelope.Root, &raw); err != nil {
+		return nil, fmt.Errorf("astjson: invalid root: %w", err)
+	}
+	return decodeRaw(raw)
+}
+
+func decodeRaw(raw map[string]interface{}) (Node, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	kindNum, ok := raw["kind"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("astjson: node is missing a numeric kind discriminator")
+	}
+	kind := ASTKind(int(kindNum))
+
+	factory, ok := nodeRegistry[kind]
+	if !ok {
+		return nil, fmt.Errorf("astjson: no node type registered for kind %s (%d); use RegisterNodeKind", kind.String(), int(kind))
+	}
+
+	pos, err := decodePosition(raw["position"])
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := factory().(type) {
+	case *ZvalNode:
+		n.BaseNode = BaseNode{Kind: kind, Position: pos}
+		n.Value = raw["value"]
+		return n, nil
+	case *ConstantNode:
+		n.BaseNode = BaseNode{Kind: kind, Position: pos}
+		n.Name, _ = raw["name"].(string)
+		return n, nil
+	case *IdentifierNode:
+		n.BaseNode = BaseNode{Kind: kind, Position: pos}
+		n.Name, _ = raw["name"].(string)
+		return n, nil
+	case *VariableNode:
+		name, err := decodeChild(raw["name"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: []Node{name}}
+		n.Name = name
+		return n, nil
+	case *UnaryNode:
+		operand, err := decodeChild(raw["operand"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: []Node{operand}}
+		n.Operator, _ = raw["operator"].(string)
+		n.Operand = operand
+		return n, nil
+	case *BinaryNode:
+		left, err := decodeChild(raw["left"])
+		if err != nil {
+			return nil, err
+		}
+		right, err := decodeChild(raw["right"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: []Node{left, right}}
+		n.Operator, _ = raw["operator"].(string)
+		n.Left, n.Right = left, right
+		return n, nil
+	case *AssignNode:
+		left, err := decodeChild(raw["left"])
+		if err != nil {
+			return nil, err
+		}
+		right, err := decodeChild(raw["right"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: []Node{left, right}}
+		n.Left, n.Right = left, right
+		n.Op, _ = raw["operator"].(string)
+		return n, nil
+	case *TernaryNode:
+		condition, err := decodeChild(raw["condition"])
+		if err != nil {
+			return nil, err
+		}
+		trueExpr, err := decodeChild(raw["true_expr"])
+		if err != nil {
+			return nil, err
+		}
+		falseExpr, err := decodeChild(raw["false_expr"])
+		if err != nil {
+			return nil, err
+		}
+		children := []Node{condition}
+		if trueExpr != nil {
+			children = append(children, trueExpr)
+		}
+		children = append(children, falseExpr)
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: children}
+		n.Condition, n.TrueExpr, n.FalseExpr = condition, trueExpr, falseExpr
+		return n, nil
+	case *CallNode:
+		callee, err := decodeChild(raw["callee"])
+		if err != nil {
+			return nil, err
+		}
+		args, err := decodeChildList(raw["arguments"])
+		if err != nil {
+			return nil, err
+		}
+		children := []Node{callee}
+		if len(args) > 0 {
+			children = append(children, NewArgumentList(args, pos))
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: children}
+		n.Callee, n.Arguments = callee, args
+		return n, nil
+	case *MethodCallNode:
+		object, err := decodeChild(raw["object"])
+		if err != nil {
+			return nil, err
+		}
+		method, err := decodeChild(raw["method"])
+		if err != nil {
+			return nil, err
+		}
+		args, err := decodeChildList(raw["arguments"])
+		if err != nil {
+			return nil, err
+		}
+		children := []Node{object, method}
+		if len(args) > 0 {
+			children = append(children, NewArgumentList(args, pos))
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: children}
+		n.Object, n.Method, n.Arguments = object, method, args
+		n.Nullsafe, _ = raw["nullsafe"].(bool)
+		return n, nil
+	case *PropertyNode:
+		object, err := decodeChild(raw["object"])
+		if err != nil {
+			return nil, err
+		}
+		property, err := decodeChild(raw["property"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: []Node{object, property}}
+		n.Object, n.Property = object, property
+		n.Nullsafe, _ = raw["nullsafe"].(bool)
+		return n, nil
+	case *ArrayAccessNode:
+		array, err := decodeChild(raw["array"])
+		if err != nil {
+			return nil, err
+		}
+		index, err := decodeChild(raw["index"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: []Node{array, index}}
+		n.Array, n.Index = array, index
+		return n, nil
+	case *ListNode:
+		elements, err := decodeChildList(raw["elements"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: elements}
+		n.Elements = elements
+		return n, nil
+	case *IfNode:
+		elements, err := decodeChildList(raw["elements"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: elements}
+		n.Elements = elements
+		return n, nil
+	case *IfElementNode:
+		condition, err := decodeChild(raw["condition"])
+		if err != nil {
+			return nil, err
+		}
+		body, err := decodeChild(raw["body"])
+		if err != nil {
+			return nil, err
+		}
+		children := []Node{}
+		if condition != nil {
+			children = append(children, condition)
+		}
+		children = append(children, body)
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: children}
+		n.Condition, n.Body = condition, body
+		return n, nil
+	case *WhileNode:
+		condition, err := decodeChild(raw["condition"])
+		if err != nil {
+			return nil, err
+		}
+		body, err := decodeChild(raw["body"])
+		if err != nil {
+			return nil, err
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: []Node{condition, body}}
+		n.Condition, n.Body = condition, body
+		return n, nil
+	case *ForNode:
+		init, err := decodeChild(raw["init"])
+		if err != nil {
+			return nil, err
+		}
+		condition, err := decodeChild(raw["condition"])
+		if err != nil {
+			return nil, err
+		}
+		update, err := decodeChild(raw["update"])
+		if err != nil {
+			return nil, err
+		}
+		body, err := decodeChild(raw["body"])
+		if err != nil {
+			return nil, err
+		}
+		var children []Node
+		for _, c := range []Node{init, condition, update, body} {
+			if c != nil {
+				children = append(children, c)
+			}
+		}
+		n.BaseNode = BaseNode{Kind: kind, Position: pos, Children: children}
+		n.Init, n.Condition, n.Update, n.Body = init, condition, update, body
+		return n, nil
+	default:
+		return nil, fmt.Errorf("astjson: kind %s (%d) is registered but decodeRaw has no case for its Go type %T", kind.String(), int(kind), n)
+	}
+}
+
+func decodeChild(raw interface{}) (Node, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("astjson: expected a node object, got %T", raw)
+	}
+	return decodeRaw(m)
+}
+
+func decodeChildList(raw interface{}) ([]Node, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("astjson: expected a node array, got %T", raw)
+	}
+	out := make([]Node, len(list))
+	for i, el := range list {
+		n, err := decodeChild(el)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// encodePosition把Position编码成普通的map[string]interface{}而不是
+// 直接把struct值塞进envelope。encoding/json通过反射能正常序列化一个
+// 裸struct，但ast/yaml.go的手写YAML writer只认识
+// map[string]interface{}/[]interface{}/标量，遇到没识别的类型会退化
+// 成调用它的Stringer、产出"1:1"这样的字符串——Position因此在YAML
+// 路径上被错误地降级成了字符串，decodePosition再把它解析回struct时
+// 就会失败。这里统一成map，JSON和YAML两条路径都能正确处理。
+func encodePosition(pos Position) map[string]interface{} {
+	return map[string]interface{}{
+		"line":   pos.Line,
+		"column": pos.Column,
+		"offset": pos.Offset,
+	}
+}
+
+func decodePosition(raw interface{}) (Position, error) {
+	if raw == nil {
+		return Position{}, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return Position{}, fmt.Errorf("astjson: invalid position: %w", err)
+	}
+	var pos Position
+	if err := json.Unmarshal(data, &pos); err != nil {
+		return Position{}, fmt.Errorf("astjson: invalid position: %w", err)
+	}
+	return pos, nil
+}
@@ -0,0 +1,105 @@
+package ast
+
+import "testing"
+
+func TestMarshalNodeUnmarshalNodeRoundTripsAssign(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	original := NewAssignNode(NewVariableNode(NewIdentifier("x", pos), pos), NewZvalNode(int64(42), pos), pos)
+
+	data, err := MarshalNode(original)
+	if err != nil {
+		t.Fatalf("MarshalNode returned an error: %v", err)
+	}
+
+	got, err := UnmarshalNode(data)
+	if err != nil {
+		t.Fatalf("UnmarshalNode returned an error: %v", err)
+	}
+
+	assign, ok := got.(*AssignNode)
+	if !ok {
+		t.Fatalf("expected *AssignNode, got %T", got)
+	}
+	variable, ok := assign.Left.(*VariableNode)
+	if !ok {
+		t.Fatalf("expected assign.Left to be *VariableNode, got %T", assign.Left)
+	}
+	ident, ok := variable.Name.(*IdentifierNode)
+	if !ok || ident.Name != "x" {
+		t.Errorf("expected variable name to round-trip to identifier %q, got %#v", "x", variable.Name)
+	}
+	zval, ok := assign.Right.(*ZvalNode)
+	if !ok {
+		t.Fatalf("expected assign.Right to be *ZvalNode, got %T", assign.Right)
+	}
+	if n, ok := zval.Value.(float64); !ok || n != 42 {
+		t.Errorf("expected zval value to round-trip to 42, got %#v", zval.Value)
+	}
+}
+
+func TestMarshalNodeUnmarshalNodeRoundTripsBinary(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	original := NewBinaryNode(AST_BINARY_OP, "+", NewZvalNode(int64(1), pos), NewZvalNode(int64(2), pos), pos)
+
+	data, err := MarshalNode(original)
+	if err != nil {
+		t.Fatalf("MarshalNode returned an error: %v", err)
+	}
+	got, err := UnmarshalNode(data)
+	if err != nil {
+		t.Fatalf("UnmarshalNode returned an error: %v", err)
+	}
+
+	binary, ok := got.(*BinaryNode)
+	if !ok {
+		t.Fatalf("expected *BinaryNode, got %T", got)
+	}
+	if binary.Operator != "+" {
+		t.Errorf("expected operator %q, got %q", "+", binary.Operator)
+	}
+	if binary.Position != pos {
+		t.Errorf("expected position %v to round-trip, got %v", pos, binary.Position)
+	}
+}
+
+func TestMarshalNodeUnmarshalNodeRoundTripsIfElseIf(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	then := NewIfElementNode(NewZvalNode(true, pos), NewZvalNode(int64(1), pos), pos)
+	elseBranch := NewIfElementNode(nil, NewZvalNode(int64(2), pos), pos)
+	original := NewIfNode([]Node{then, elseBranch}, pos)
+
+	data, err := MarshalNode(original)
+	if err != nil {
+		t.Fatalf("MarshalNode returned an error: %v", err)
+	}
+	got, err := UnmarshalNode(data)
+	if err != nil {
+		t.Fatalf("UnmarshalNode returned an error: %v", err)
+	}
+
+	ifNode, ok := got.(*IfNode)
+	if !ok {
+		t.Fatalf("expected *IfNode, got %T", got)
+	}
+	if len(ifNode.Elements) != 2 {
+		t.Fatalf("expected 2 if-elements, got %d", len(ifNode.Elements))
+	}
+	lastElem, ok := ifNode.Elements[1].(*IfElementNode)
+	if !ok || lastElem.Condition != nil {
+		t.Errorf("expected the last if-element to round-trip as an else branch with a nil condition")
+	}
+}
+
+func TestUnmarshalNodeRejectsUnknownSchemaVersion(t *testing.T) {
+	_, err := UnmarshalNode([]byte(`{"schema_version":"99","root":{"kind":0}}`))
+	if err == nil {
+		t.Errorf("expected an error for an unsupported schema_version, got nil")
+	}
+}
+
+func TestUnmarshalNodeRejectsUnregisteredKind(t *testing.T) {
+	_, err := UnmarshalNode([]byte(`{"schema_version":"1","root":{"kind":9999}}`))
+	if err == nil {
+		t.Errorf("expected an error for a kind with no registered factory, got nil")
+	}
+}
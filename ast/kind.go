@@ -1,61 +1,80 @@
 package ast
 
-import "fmt"
-
-// ASTKind 表示AST节点类型，对应PHP官方zend_ast.h的定义
+// ASTKind 表示AST节点类型。自本次对齐之后，数值尽量复用php-ast
+// (PECL ast扩展, https://github.com/nikic/php-ast)发布的常量值，
+// 使consumer可以把这里产出的整数kind和已有的、基于php-ast的工具
+// (如Phan)互通，而不需要再维护一套自说自话的编号。
+//
+// 两个例外是AST_ZVAL/AST_ZNODE：php-ast里标量字面量直接以裸PHP值
+// 出现在树中，没有对应的公开kind；这里继续用专门的节点表示字面量，
+// 因此给它们分配了不会与任何php-ast常量冲突的负值。
 type ASTKind int
 
-// 特殊节点 (0-3) - ZEND_AST_SPECIAL
+// 内部专用节点，没有对应的php-ast公开常量
 const (
-	AST_ZVAL     ASTKind = 0  // 字面量值
-	AST_CONSTANT ASTKind = 1  // 命名常量
-	AST_ZNODE    ASTKind = 2  // 编译时节点(内部使用)
-	AST_FUNC_DECL ASTKind = 3 // 函数声明(特殊处理)
+	AST_ZNODE ASTKind = -2 // 编译时占位节点(内部使用)
+	AST_ZVAL  ASTKind = -1 // 字面量值(php-ast中标量以裸值表示，没有wrapping kind)
 )
 
-// 声明节点 (64-73) - ZEND_AST_SPECIAL + 固定子节点数
+// 0个子节点的叶子节点 (0-4)
 const (
-	AST_CLOSURE     ASTKind = 64 // 匿名函数/闭包
-	AST_METHOD      ASTKind = 65 // 类方法
-	AST_CLASS       ASTKind = 66 // 类声明
-	AST_ARROW_FUNC  ASTKind = 67 // 箭头函数
-	AST_ENUM        ASTKind = 68 // 枚举声明
+	AST_MAGIC_CONST      ASTKind = 0 // 魔术常量 (__FILE__, __LINE__, etc.)
+	AST_TYPE             ASTKind = 1 // 标量类型声明
+	AST_CONSTANT         ASTKind = 2 // 命名常量引用
+	AST_CALLABLE_CONVERT ASTKind = 3 // 一等可调用语法 foo(...) (PHP 8.1)
+	// AST_IDENTIFIER 标识符(函数名、类名、属性名等裸名字，不经过变
+	// 量/常量查找)。之前IdentifierNode借用了AST_CONSTANT的kind("标
+	// 识符作为常量处理")，导致按GetKind()分派的visitor没法区分"引
+	// 用一个常量"和"提到一个名字"这两件语义上完全不同的事。和
+	// AST_ZVAL/AST_ZNODE一样，真实的php-ast里标识符也是以裸字符串
+	// 出现、没有wrapping kind，这里给它一个仅本仓库内部使用的正值，
+	// 不与任何php-ast公开常量冲突
+	AST_IDENTIFIER ASTKind = 4
 )
 
-// 列表节点 (128-149) - ZEND_AST_IS_LIST + 可变子节点数
+// 声明节点 (67-72) - 各自有固定但需要专门处理的子节点布局
 const (
-	AST_ARG_LIST            ASTKind = 128 // 参数列表
-	AST_ARRAY               ASTKind = 129 // 数组字面量
-	AST_ENCAPS_LIST         ASTKind = 130 // 字符串插值列表
-	AST_EXPR_LIST           ASTKind = 131 // 表达式列表
-	AST_STMT_LIST           ASTKind = 132 // 语句列表
-	AST_IF                  ASTKind = 133 // if语句链
-	AST_SWITCH_LIST         ASTKind = 134 // switch案例列表
-	AST_CATCH_LIST          ASTKind = 135 // catch子句列表
-	AST_PARAM_LIST          ASTKind = 136 // 形参列表
-	AST_CLOSURE_USES        ASTKind = 137 // use变量列表
-	AST_PROP_GROUP          ASTKind = 138 // 属性组
-	AST_CONST_DECL          ASTKind = 139 // 常量声明列表
-	AST_CLASS_CONST_GROUP   ASTKind = 140 // 类常量组
-	AST_NAME_LIST           ASTKind = 141 // 名称列表
-	AST_TRAIT_ADAPTATIONS   ASTKind = 142 // trait适配列表
-	AST_USE                 ASTKind = 143 // use声明列表
-	AST_ATTRIBUTE_GROUP     ASTKind = 144 // 属性组
-	AST_MATCH_ARM_LIST      ASTKind = 145 // match分支列表
-	AST_ENUM_CASE_LIST      ASTKind = 146 // 枚举案例列表
-	AST_PROPERTY_HOOK_LIST  ASTKind = 147 // 属性钩子列表
+	AST_FUNC_DECL  ASTKind = 67 // 函数声明
+	AST_CLOSURE    ASTKind = 68 // 匿名函数/闭包
+	AST_METHOD     ASTKind = 69 // 类方法
+	AST_CLASS      ASTKind = 70 // 类声明
+	AST_ARROW_FUNC ASTKind = 71 // 箭头函数
+	AST_ENUM       ASTKind = 72 // 枚举声明 (PHP 8.1)
 )
 
-// 表达式节点 - 0个子节点 (256-257)
+// 列表节点 (128-147) - IS_LIST标记 + 可变子节点数
 const (
-	AST_MAGIC_CONST ASTKind = 256 // 魔术常量 (__FILE__, __LINE__, etc.)
-	AST_TYPE        ASTKind = 257 // 类型声明
+	AST_ARG_LIST           ASTKind = 128 // 参数列表
+	AST_ARRAY              ASTKind = 129 // 数组字面量
+	AST_ENCAPS_LIST        ASTKind = 130 // 字符串插值列表
+	AST_EXPR_LIST          ASTKind = 131 // 表达式列表
+	AST_STMT_LIST          ASTKind = 132 // 语句列表
+	AST_IF                 ASTKind = 133 // if语句链
+	AST_SWITCH_LIST        ASTKind = 134 // switch案例列表
+	AST_CATCH_LIST         ASTKind = 135 // catch子句列表
+	AST_PARAM_LIST         ASTKind = 136 // 形参列表
+	AST_CLOSURE_USES       ASTKind = 137 // use变量列表
+	AST_PROP_DECL          ASTKind = 138 // 属性声明列表(原AST_PROP_GROUP)
+	AST_CONST_DECL         ASTKind = 139 // 常量声明列表
+	AST_CLASS_CONST_GROUP  ASTKind = 140 // 类常量组
+	AST_NAME_LIST          ASTKind = 141 // 名称列表
+	AST_TRAIT_ADAPTATIONS  ASTKind = 142 // trait适配列表
+	AST_USE                ASTKind = 143 // use声明列表
+	AST_MATCH_ARM_LIST     ASTKind = 144 // match分支列表
+	AST_ENUM_CASE_LIST     ASTKind = 145 // 枚举案例列表
+	AST_ATTRIBUTE_LIST     ASTKind = 146 // 属性列表 #[A, B]
+	AST_PROPERTY_HOOK_LIST ASTKind = 147 // 属性钩子列表
 )
 
+// AST_LIST 是list()解构赋值的目标列表。php-ast把它放在了常规列表
+// 区间(128-147)之外的255，这里保持一致；getChildCount/IsList对它
+// 做了特判
+const AST_LIST ASTKind = 255
+
 // 表达式节点 - 1个子节点 (320-351)
 const (
 	AST_VAR                   ASTKind = 320 // 变量 $var
-	AST_CONST                 ASTKind = 321 // 常量引用
+	AST_CONST                 ASTKind = 321 // 常量引用(含命名空间解析)
 	AST_UNPACK                ASTKind = 322 // 解包操作 ...$arr
 	AST_UNARY_PLUS            ASTKind = 323 // 一元加 +$x
 	AST_UNARY_MINUS           ASTKind = 324 // 一元减 -$x
@@ -114,7 +133,7 @@ const (
 	AST_PARAM                  ASTKind = 406 // 函数参数
 	AST_TYPE_UNION             ASTKind = 407 // 联合类型 Type1|Type2
 	AST_TYPE_INTERSECTION      ASTKind = 408 // 交集类型 Type1&Type2
-	AST_ATTRIBUTE              ASTKind = 409 // 属性 #[Attribute]
+	AST_ATTRIBUTE_GROUP        ASTKind = 409 // 单个属性 #[Attribute(args)]
 	AST_MATCH_ARM              ASTKind = 410 // match分支
 	AST_ENUM_CASE              ASTKind = 411 // 枚举案例
 	AST_PROPERTY_HOOK          ASTKind = 412 // 属性钩子
@@ -133,385 +152,31 @@ const (
 
 // 表达式节点 - 4个子节点 (512-517)
 const (
-	AST_FOR     ASTKind = 512 // for循环
-	AST_SWITCH  ASTKind = 513 // switch语句
+	AST_FOR    ASTKind = 512 // for循环
+	AST_SWITCH ASTKind = 513 // switch语句
 )
 
 // 声明元素节点 (768-777)
 const (
-	AST_PROP_ELEM         ASTKind = 768 // 属性元素
-	AST_CONST_ELEM        ASTKind = 769 // 常量元素
-	AST_USE_TRAIT         ASTKind = 770 // trait使用
-	AST_TRAIT_PRECEDENCE  ASTKind = 771 // trait优先级
-	AST_METHOD_REFERENCE  ASTKind = 772 // 方法引用
-	AST_NAMESPACE         ASTKind = 773 // 命名空间
-	AST_USE_ELEM          ASTKind = 774 // use元素
-	AST_TRAIT_ALIAS       ASTKind = 775 // trait别名
-	AST_GROUP_USE         ASTKind = 776 // 分组use
-	AST_CLASS_NAME        ASTKind = 777 // 类名
+	AST_PROP_ELEM        ASTKind = 768 // 属性元素
+	AST_CONST_ELEM       ASTKind = 769 // 常量元素
+	AST_USE_TRAIT        ASTKind = 770 // trait使用
+	AST_TRAIT_PRECEDENCE ASTKind = 771 // trait优先级
+	AST_METHOD_REFERENCE ASTKind = 772 // 方法引用
+	AST_NAMESPACE        ASTKind = 773 // 命名空间
+	AST_USE_ELEM         ASTKind = 774 // use元素
+	AST_TRAIT_ALIAS      ASTKind = 775 // trait别名
+	AST_GROUP_USE        ASTKind = 776 // 分组use
+	AST_CLASS_NAME       ASTKind = 777 // 类名
 )
 
-// getChildCount 根据AST节点类型返回期望的子节点数量
-func (k ASTKind) getChildCount() int {
-	switch {
-	// 特殊节点
-	case k <= 3:
-		return -1 // 特殊处理
-	
-	// 声明节点 - 各有不同的子节点数
-	case k >= 64 && k <= 73:
-		switch k {
-		case AST_CLOSURE:
-			return 5 // name, params, uses, stmts, return_type
-		case AST_METHOD:
-			return 6 // flags, name, params, return_type, stmts, doc_comment
-		case AST_CLASS:
-			return 5 // flags, name, extends, implements, stmts
-		case AST_ARROW_FUNC:
-			return 4 // params, return_type, expr, static
-		case AST_ENUM:
-			return 5 // flags, name, type, implements, stmts
-		}
-		return -1
-	
-	// 列表节点 - 可变长度
-	case k >= 128 && k <= 149:
-		return -1 // 可变长度列表
-	
-	// 0个子节点
-	case k >= 256 && k <= 257:
-		return 0
-	
-	// 1个子节点
-	case k >= 320 && k <= 351:
-		return 1
-	
-	// 2个子节点  
-	case k >= 384 && k <= 415:
-		return 2
-	
-	// 3个子节点
-	case k >= 448 && k <= 463:
-		return 3
-	
-	// 4个子节点
-	case k >= 512 && k <= 517:
-		return 4
-	
-	// 声明元素节点 - 各有不同的子节点数
-	case k >= 768 && k <= 777:
-		switch k {
-		case AST_PROP_ELEM:
-			return 2 // name, default
-		case AST_CONST_ELEM:
-			return 2 // name, value
-		case AST_USE_TRAIT:
-			return 2 // name, adaptations
-		case AST_TRAIT_PRECEDENCE:
-			return 2 // method, insteadof
-		case AST_METHOD_REFERENCE:
-			return 2 // class, method
-		case AST_NAMESPACE:
-			return 2 // name, stmts
-		case AST_USE_ELEM:
-			return 2 // name, alias
-		case AST_TRAIT_ALIAS:
-			return 3 // method, alias, modifiers
-		case AST_GROUP_USE:
-			return 2 // prefix, uses
-		case AST_CLASS_NAME:
-			return 1 // name
-		}
-		return -1
-	
-	default:
-		return -1 // 未知类型
-	}
-}
-
-// String 返回AST节点类型的字符串表示
-func (k ASTKind) String() string {
-	switch k {
-	// 特殊节点
-	case AST_ZVAL:
-		return "AST_ZVAL"
-	case AST_CONSTANT:
-		return "AST_CONSTANT"
-	case AST_ZNODE:
-		return "AST_ZNODE"
-	case AST_FUNC_DECL:
-		return "AST_FUNC_DECL"
-	
-	// 声明节点
-	case AST_CLOSURE:
-		return "AST_CLOSURE"
-	case AST_METHOD:
-		return "AST_METHOD"
-	case AST_CLASS:
-		return "AST_CLASS"
-	case AST_ARROW_FUNC:
-		return "AST_ARROW_FUNC"
-	case AST_ENUM:
-		return "AST_ENUM"
-	
-	// 列表节点
-	case AST_ARG_LIST:
-		return "AST_ARG_LIST"
-	case AST_ARRAY:
-		return "AST_ARRAY"
-	case AST_ENCAPS_LIST:
-		return "AST_ENCAPS_LIST"
-	case AST_EXPR_LIST:
-		return "AST_EXPR_LIST"
-	case AST_STMT_LIST:
-		return "AST_STMT_LIST"
-	case AST_IF:
-		return "AST_IF"
-	case AST_SWITCH_LIST:
-		return "AST_SWITCH_LIST"
-	case AST_CATCH_LIST:
-		return "AST_CATCH_LIST"
-	case AST_PARAM_LIST:
-		return "AST_PARAM_LIST"
-	case AST_CLOSURE_USES:
-		return "AST_CLOSURE_USES"
-	case AST_PROP_GROUP:
-		return "AST_PROP_GROUP"
-	case AST_CONST_DECL:
-		return "AST_CONST_DECL"
-	case AST_CLASS_CONST_GROUP:
-		return "AST_CLASS_CONST_GROUP"
-	case AST_NAME_LIST:
-		return "AST_NAME_LIST"
-	case AST_TRAIT_ADAPTATIONS:
-		return "AST_TRAIT_ADAPTATIONS"
-	case AST_USE:
-		return "AST_USE"
-	case AST_ATTRIBUTE_GROUP:
-		return "AST_ATTRIBUTE_GROUP"
-	case AST_MATCH_ARM_LIST:
-		return "AST_MATCH_ARM_LIST"
-	case AST_ENUM_CASE_LIST:
-		return "AST_ENUM_CASE_LIST"
-	case AST_PROPERTY_HOOK_LIST:
-		return "AST_PROPERTY_HOOK_LIST"
-	
-	// 0个子节点的表达式
-	case AST_MAGIC_CONST:
-		return "AST_MAGIC_CONST"
-	case AST_TYPE:
-		return "AST_TYPE"
-	
-	// 1个子节点的表达式
-	case AST_VAR:
-		return "AST_VAR"
-	case AST_CONST:
-		return "AST_CONST"
-	case AST_UNPACK:
-		return "AST_UNPACK"
-	case AST_UNARY_PLUS:
-		return "AST_UNARY_PLUS"
-	case AST_UNARY_MINUS:
-		return "AST_UNARY_MINUS"
-	case AST_CAST:
-		return "AST_CAST"
-	case AST_EMPTY:
-		return "AST_EMPTY"
-	case AST_ISSET:
-		return "AST_ISSET"
-	case AST_SILENCE:
-		return "AST_SILENCE"
-	case AST_SHELL_EXEC:
-		return "AST_SHELL_EXEC"
-	case AST_CLONE:
-		return "AST_CLONE"
-	case AST_EXIT:
-		return "AST_EXIT"
-	case AST_PRINT:
-		return "AST_PRINT"
-	case AST_INCLUDE_OR_EVAL:
-		return "AST_INCLUDE_OR_EVAL"
-	case AST_UNARY_OP:
-		return "AST_UNARY_OP"
-	case AST_PRE_INC:
-		return "AST_PRE_INC"
-	case AST_PRE_DEC:
-		return "AST_PRE_DEC"
-	case AST_POST_INC:
-		return "AST_POST_INC"
-	case AST_POST_DEC:
-		return "AST_POST_DEC"
-	case AST_YIELD_FROM:
-		return "AST_YIELD_FROM"
-	case AST_GLOBAL:
-		return "AST_GLOBAL"
-	case AST_UNSET:
-		return "AST_UNSET"
-	case AST_RETURN:
-		return "AST_RETURN"
-	case AST_LABEL:
-		return "AST_LABEL"
-	case AST_REF:
-		return "AST_REF"
-	case AST_HALT_COMPILER:
-		return "AST_HALT_COMPILER"
-	case AST_ECHO:
-		return "AST_ECHO"
-	case AST_THROW:
-		return "AST_THROW"
-	case AST_GOTO:
-		return "AST_GOTO"
-	case AST_BREAK:
-		return "AST_BREAK"
-	case AST_CONTINUE:
-		return "AST_CONTINUE"
-	
-	// 2个子节点的表达式
-	case AST_DIM:
-		return "AST_DIM"
-	case AST_PROP:
-		return "AST_PROP"
-	case AST_NULLSAFE_PROP:
-		return "AST_NULLSAFE_PROP"
-	case AST_STATIC_PROP:
-		return "AST_STATIC_PROP"
-	case AST_CALL:
-		return "AST_CALL"
-	case AST_CLASS_CONST:
-		return "AST_CLASS_CONST"
-	case AST_ASSIGN:
-		return "AST_ASSIGN"
-	case AST_ASSIGN_REF:
-		return "AST_ASSIGN_REF"
-	case AST_ASSIGN_OP:
-		return "AST_ASSIGN_OP"
-	case AST_BINARY_OP:
-		return "AST_BINARY_OP"
-	case AST_ARRAY_ELEM:
-		return "AST_ARRAY_ELEM"
-	case AST_NEW:
-		return "AST_NEW"
-	case AST_INSTANCEOF:
-		return "AST_INSTANCEOF"
-	case AST_YIELD:
-		return "AST_YIELD"
-	case AST_COALESCE:
-		return "AST_COALESCE"
-	case AST_ASSIGN_COALESCE:
-		return "AST_ASSIGN_COALESCE"
-	case AST_STATIC:
-		return "AST_STATIC"
-	case AST_WHILE:
-		return "AST_WHILE"
-	case AST_DO_WHILE:
-		return "AST_DO_WHILE"
-	case AST_IF_ELEM:
-		return "AST_IF_ELEM"
-	case AST_SWITCH_CASE:
-		return "AST_SWITCH_CASE"
-	case AST_CATCH:
-		return "AST_CATCH"
-	case AST_PARAM:
-		return "AST_PARAM"
-	case AST_TYPE_UNION:
-		return "AST_TYPE_UNION"
-	case AST_TYPE_INTERSECTION:
-		return "AST_TYPE_INTERSECTION"
-	case AST_ATTRIBUTE:
-		return "AST_ATTRIBUTE"
-	case AST_MATCH_ARM:
-		return "AST_MATCH_ARM"
-	case AST_ENUM_CASE:
-		return "AST_ENUM_CASE"
-	case AST_PROPERTY_HOOK:
-		return "AST_PROPERTY_HOOK"
-	
-	// 3个子节点的表达式
-	case AST_METHOD_CALL:
-		return "AST_METHOD_CALL"
-	case AST_NULLSAFE_METHOD_CALL:
-		return "AST_NULLSAFE_METHOD_CALL"
-	case AST_STATIC_CALL:
-		return "AST_STATIC_CALL"
-	case AST_CONDITIONAL:
-		return "AST_CONDITIONAL"
-	case AST_TRY:
-		return "AST_TRY"
-	case AST_FOREACH:
-		return "AST_FOREACH"
-	case AST_DECLARE:
-		return "AST_DECLARE"
-	
-	// 4个子节点的表达式
-	case AST_FOR:
-		return "AST_FOR"
-	case AST_SWITCH:
-		return "AST_SWITCH"
-	
-	// 声明元素节点
-	case AST_PROP_ELEM:
-		return "AST_PROP_ELEM"
-	case AST_CONST_ELEM:
-		return "AST_CONST_ELEM"
-	case AST_USE_TRAIT:
-		return "AST_USE_TRAIT"
-	case AST_TRAIT_PRECEDENCE:
-		return "AST_TRAIT_PRECEDENCE"
-	case AST_METHOD_REFERENCE:
-		return "AST_METHOD_REFERENCE"
-	case AST_NAMESPACE:
-		return "AST_NAMESPACE"
-	case AST_USE_ELEM:
-		return "AST_USE_ELEM"
-	case AST_TRAIT_ALIAS:
-		return "AST_TRAIT_ALIAS"
-	case AST_GROUP_USE:
-		return "AST_GROUP_USE"
-	case AST_CLASS_NAME:
-		return "AST_CLASS_NAME"
-	
-	default:
-		return fmt.Sprintf("UNKNOWN_AST_KIND_%d", int(k))
-	}
-}
-
-// IsSpecial 检查是否为特殊节点
-func (k ASTKind) IsSpecial() bool {
-	return k <= 3 || (k >= 64 && k <= 73)
-}
-
-// IsList 检查是否为列表节点
-func (k ASTKind) IsList() bool {
-	return k >= 128 && k <= 149
-}
-
-// IsExpression 检查是否为表达式节点
-func (k ASTKind) IsExpression() bool {
-	return (k >= 256 && k <= 257) || 
-		   (k >= 320 && k <= 351) ||
-		   (k >= 384 && k <= 415) ||
-		   (k >= 448 && k <= 463) ||
-		   (k >= 512 && k <= 517)
-}
-
-// IsStatement 检查是否为语句节点 
-func (k ASTKind) IsStatement() bool {
-	// 大部分语句节点在列表节点和表达式节点中
-	switch k {
-	case AST_STMT_LIST, AST_IF, AST_SWITCH_LIST, 
-		 AST_WHILE, AST_DO_WHILE, AST_FOR, AST_FOREACH,
-		 AST_TRY, AST_DECLARE, AST_RETURN, AST_BREAK,
-		 AST_CONTINUE, AST_ECHO, AST_GLOBAL, AST_STATIC,
-		 AST_UNSET, AST_GOTO, AST_LABEL:
-		return true
-	default:
-		return false
-	}
-}
+// 补充节点 (2048+)。php-ast把这些晚于初版引入、不想打乱已有编号的
+// kind放在了单独的高位区间
+const (
+	AST_NAME          ASTKind = 2048 // 限定/非限定名称引用
+	AST_CLOSURE_VAR   ASTKind = 2049 // 闭包use()变量项
+	AST_NULLABLE_TYPE ASTKind = 2050 // 可为空类型 ?Type
+)
 
-// IsDeclaration 检查是否为声明节点
-func (k ASTKind) IsDeclaration() bool {
-	return (k >= 64 && k <= 73) || k == AST_FUNC_DECL ||
-		   (k >= 768 && k <= 777) || k == AST_CONST_DECL ||
-		   k == AST_PROP_GROUP || k == AST_CLASS_CONST_GROUP
-}
\ No newline at end of file
+// String()/getChildCount()/IsSpecial()等分类方法，以及每个kind对应的
+// kindDescriptor，都在kindflags.go里统一维护，不在这里重复实现。
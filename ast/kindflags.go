@@ -0,0 +1,554 @@
+package ast
+
+import "fmt"
+
+// KindCategory 是ASTKind分类信息的位掩码：Special/List/Expression/
+// Statement/Declaration各占一位，一次Categories()查表即可拿到全部
+// 分类，不需要为每个IsXxx()方法单独重新做一遍区间比较
+type KindCategory uint8
+
+const (
+	CategorySpecial KindCategory = 1 << iota
+	CategoryList
+	CategoryExpression
+	CategoryStatement
+	CategoryDeclaration
+)
+
+// Has 检查c是否包含cat这个分类位，供外部调用方(不只是本包内的
+// IsXxx()方法)直接查询某个kind属于哪些类别，比如
+// `kind.Categories().Has(ast.CategoryStatement)`
+func (c KindCategory) Has(cat KindCategory) bool {
+	return c&cat != 0
+}
+
+// kindDescriptor 聚合了一个ASTKind在本包里需要用到的全部静态信息：
+// 打印用的名字(String())、期望子节点数(getChildCount())、分类位掩码
+// (Categories())。三者以前分别是kind.go里三套独立维护的switch/区间
+// 判断，新增一个kind时很容易漏掉其中一个；现在它们是kindDescriptorTable
+// 这一张表的三个字段，由同一次init()按allASTKinds(kind.go里每个声明
+// 过的ASTKind常量的穷举列表)填充，kindflags_test.go会对着
+// allASTKinds逐个检查表里有没有对应条目，新kind漏了登记会直接测试
+// 失败，不会默默地在String()/getChildCount()/Categories()里表现成
+// "未知kind"
+type kindDescriptor struct {
+	name       string
+	childCount int
+	categories KindCategory
+}
+
+// allASTKinds 穷举kind.go里声明的每一个ASTKind常量。kindDescriptorTable
+// 由它驱动填充，kindflags_test.go也由它驱动做穷尽性检查——kind.go里
+// 新增一个常量而忘了加到这里，马上会被测试捕获，而不是等到某个
+// String()/getChildCount()调用默默返回"未知"才发现
+var allASTKinds = []ASTKind{
+	AST_ZNODE, AST_ZVAL,
+
+	AST_MAGIC_CONST, AST_TYPE, AST_CONSTANT, AST_CALLABLE_CONVERT, AST_IDENTIFIER,
+
+	AST_FUNC_DECL, AST_CLOSURE, AST_METHOD, AST_CLASS, AST_ARROW_FUNC, AST_ENUM,
+
+	AST_ARG_LIST, AST_ARRAY, AST_ENCAPS_LIST, AST_EXPR_LIST, AST_STMT_LIST,
+	AST_IF, AST_SWITCH_LIST, AST_CATCH_LIST, AST_PARAM_LIST, AST_CLOSURE_USES,
+	AST_PROP_DECL, AST_CONST_DECL, AST_CLASS_CONST_GROUP, AST_NAME_LIST,
+	AST_TRAIT_ADAPTATIONS, AST_USE, AST_MATCH_ARM_LIST, AST_ENUM_CASE_LIST,
+	AST_ATTRIBUTE_LIST, AST_PROPERTY_HOOK_LIST,
+
+	AST_LIST,
+
+	AST_VAR, AST_CONST, AST_UNPACK, AST_UNARY_PLUS, AST_UNARY_MINUS, AST_CAST,
+	AST_EMPTY, AST_ISSET, AST_SILENCE, AST_SHELL_EXEC, AST_CLONE, AST_EXIT,
+	AST_PRINT, AST_INCLUDE_OR_EVAL, AST_UNARY_OP, AST_PRE_INC, AST_PRE_DEC,
+	AST_POST_INC, AST_POST_DEC, AST_YIELD_FROM, AST_GLOBAL, AST_UNSET,
+	AST_RETURN, AST_LABEL, AST_REF, AST_HALT_COMPILER, AST_ECHO, AST_THROW,
+	AST_GOTO, AST_BREAK, AST_CONTINUE,
+
+	AST_DIM, AST_PROP, AST_NULLSAFE_PROP, AST_STATIC_PROP, AST_CALL,
+	AST_CLASS_CONST, AST_ASSIGN, AST_ASSIGN_REF, AST_ASSIGN_OP, AST_BINARY_OP,
+	AST_ARRAY_ELEM, AST_NEW, AST_INSTANCEOF, AST_YIELD, AST_COALESCE,
+	AST_ASSIGN_COALESCE, AST_STATIC, AST_WHILE, AST_DO_WHILE, AST_IF_ELEM,
+	AST_SWITCH_CASE, AST_CATCH, AST_PARAM, AST_TYPE_UNION, AST_TYPE_INTERSECTION,
+	AST_ATTRIBUTE_GROUP, AST_MATCH_ARM, AST_ENUM_CASE, AST_PROPERTY_HOOK,
+
+	AST_METHOD_CALL, AST_NULLSAFE_METHOD_CALL, AST_STATIC_CALL, AST_CONDITIONAL,
+	AST_TRY, AST_FOREACH, AST_DECLARE,
+
+	AST_FOR, AST_SWITCH,
+
+	AST_PROP_ELEM, AST_CONST_ELEM, AST_USE_TRAIT, AST_TRAIT_PRECEDENCE,
+	AST_METHOD_REFERENCE, AST_NAMESPACE, AST_USE_ELEM, AST_TRAIT_ALIAS,
+	AST_GROUP_USE, AST_CLASS_NAME,
+
+	AST_NAME, AST_CLOSURE_VAR, AST_NULLABLE_TYPE,
+}
+
+var kindDescriptorTable = func() map[ASTKind]kindDescriptor {
+	table := make(map[ASTKind]kindDescriptor, len(allASTKinds))
+	for _, k := range allASTKinds {
+		table[k] = kindDescriptor{
+			name:       kindNameSlow(k),
+			childCount: kindChildCountSlow(k),
+			categories: kindCategoriesSlow(k),
+		}
+	}
+	return table
+}()
+
+// kindCategoriesSlow组合isSpecialSlow/isListSlow/isExpressionSlow/
+// isStatementSlow/isDeclarationSlow的结果成一个KindCategory。只在
+// 构建kindDescriptorTable时对allASTKinds里的每个kind调用一次，不是
+// 运行期的热路径
+func kindCategoriesSlow(k ASTKind) KindCategory {
+	var c KindCategory
+	if k.isSpecialSlow() {
+		c |= CategorySpecial
+	}
+	if k.isListSlow() {
+		c |= CategoryList
+	}
+	if k.isExpressionSlow() {
+		c |= CategoryExpression
+	}
+	if k.isStatementSlow() {
+		c |= CategoryStatement
+	}
+	if k.isDeclarationSlow() {
+		c |= CategoryDeclaration
+	}
+	return c
+}
+
+// isSpecialSlow 检查是否为需要专门处理的特殊节点：内部专用的字面量/
+// 占位节点，以及子节点布局因声明种类而异的声明节点。只在构建
+// kindDescriptorTable时调用
+func (k ASTKind) isSpecialSlow() bool {
+	return k == AST_ZVAL || k == AST_ZNODE || (k >= 64 && k <= 73)
+}
+
+// isListSlow 检查是否为列表节点(含落在常规区间之外的AST_LIST)，参见
+// isSpecialSlow的说明
+func (k ASTKind) isListSlow() bool {
+	return (k >= 128 && k <= 149) || k == AST_LIST
+}
+
+// isExpressionSlow 检查是否为表达式节点，参见isSpecialSlow的说明
+func (k ASTKind) isExpressionSlow() bool {
+	return (k >= 0 && k <= 4) ||
+		(k >= 320 && k <= 351) ||
+		k == AST_NAME || k == AST_CLOSURE_VAR || k == AST_NULLABLE_TYPE ||
+		(k >= 384 && k <= 415) ||
+		(k >= 448 && k <= 463) ||
+		(k >= 512 && k <= 517)
+}
+
+// isStatementSlow 检查是否为语句节点，参见isSpecialSlow的说明
+func (k ASTKind) isStatementSlow() bool {
+	switch k {
+	case AST_STMT_LIST, AST_IF, AST_SWITCH_LIST,
+		AST_WHILE, AST_DO_WHILE, AST_FOR, AST_FOREACH,
+		AST_TRY, AST_DECLARE, AST_RETURN, AST_BREAK,
+		AST_CONTINUE, AST_ECHO, AST_GLOBAL, AST_STATIC,
+		AST_UNSET, AST_GOTO, AST_LABEL, AST_THROW,
+		AST_HALT_COMPILER:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDeclarationSlow 检查是否为声明节点，参见isSpecialSlow的说明
+func (k ASTKind) isDeclarationSlow() bool {
+	return (k >= 64 && k <= 73) ||
+		(k >= 768 && k <= 777) || k == AST_CONST_DECL ||
+		k == AST_PROP_DECL || k == AST_CLASS_CONST_GROUP
+}
+
+// kindNameSlow是String()的参照实现：把ASTKind翻译成它的常量名字符
+// 串。只在构建kindDescriptorTable时调用一次；运行期String()直接查
+// 表，不会重新走这个switch
+func kindNameSlow(k ASTKind) string {
+	switch k {
+	case AST_ZVAL:
+		return "AST_ZVAL"
+	case AST_ZNODE:
+		return "AST_ZNODE"
+	case AST_MAGIC_CONST:
+		return "AST_MAGIC_CONST"
+	case AST_TYPE:
+		return "AST_TYPE"
+	case AST_CONSTANT:
+		return "AST_CONSTANT"
+	case AST_CALLABLE_CONVERT:
+		return "AST_CALLABLE_CONVERT"
+	case AST_IDENTIFIER:
+		return "AST_IDENTIFIER"
+	case AST_FUNC_DECL:
+		return "AST_FUNC_DECL"
+	case AST_CLOSURE:
+		return "AST_CLOSURE"
+	case AST_METHOD:
+		return "AST_METHOD"
+	case AST_CLASS:
+		return "AST_CLASS"
+	case AST_ARROW_FUNC:
+		return "AST_ARROW_FUNC"
+	case AST_ENUM:
+		return "AST_ENUM"
+	case AST_ARG_LIST:
+		return "AST_ARG_LIST"
+	case AST_ARRAY:
+		return "AST_ARRAY"
+	case AST_ENCAPS_LIST:
+		return "AST_ENCAPS_LIST"
+	case AST_EXPR_LIST:
+		return "AST_EXPR_LIST"
+	case AST_STMT_LIST:
+		return "AST_STMT_LIST"
+	case AST_IF:
+		return "AST_IF"
+	case AST_SWITCH_LIST:
+		return "AST_SWITCH_LIST"
+	case AST_CATCH_LIST:
+		return "AST_CATCH_LIST"
+	case AST_PARAM_LIST:
+		return "AST_PARAM_LIST"
+	case AST_CLOSURE_USES:
+		return "AST_CLOSURE_USES"
+	case AST_PROP_DECL:
+		return "AST_PROP_DECL"
+	case AST_CONST_DECL:
+		return "AST_CONST_DECL"
+	case AST_CLASS_CONST_GROUP:
+		return "AST_CLASS_CONST_GROUP"
+	case AST_NAME_LIST:
+		return "AST_NAME_LIST"
+	case AST_TRAIT_ADAPTATIONS:
+		return "AST_TRAIT_ADAPTATIONS"
+	case AST_USE:
+		return "AST_USE"
+	case AST_MATCH_ARM_LIST:
+		return "AST_MATCH_ARM_LIST"
+	case AST_ENUM_CASE_LIST:
+		return "AST_ENUM_CASE_LIST"
+	case AST_ATTRIBUTE_LIST:
+		return "AST_ATTRIBUTE_LIST"
+	case AST_PROPERTY_HOOK_LIST:
+		return "AST_PROPERTY_HOOK_LIST"
+	case AST_LIST:
+		return "AST_LIST"
+	case AST_VAR:
+		return "AST_VAR"
+	case AST_CONST:
+		return "AST_CONST"
+	case AST_UNPACK:
+		return "AST_UNPACK"
+	case AST_UNARY_PLUS:
+		return "AST_UNARY_PLUS"
+	case AST_UNARY_MINUS:
+		return "AST_UNARY_MINUS"
+	case AST_CAST:
+		return "AST_CAST"
+	case AST_EMPTY:
+		return "AST_EMPTY"
+	case AST_ISSET:
+		return "AST_ISSET"
+	case AST_SILENCE:
+		return "AST_SILENCE"
+	case AST_SHELL_EXEC:
+		return "AST_SHELL_EXEC"
+	case AST_CLONE:
+		return "AST_CLONE"
+	case AST_EXIT:
+		return "AST_EXIT"
+	case AST_PRINT:
+		return "AST_PRINT"
+	case AST_INCLUDE_OR_EVAL:
+		return "AST_INCLUDE_OR_EVAL"
+	case AST_UNARY_OP:
+		return "AST_UNARY_OP"
+	case AST_PRE_INC:
+		return "AST_PRE_INC"
+	case AST_PRE_DEC:
+		return "AST_PRE_DEC"
+	case AST_POST_INC:
+		return "AST_POST_INC"
+	case AST_POST_DEC:
+		return "AST_POST_DEC"
+	case AST_YIELD_FROM:
+		return "AST_YIELD_FROM"
+	case AST_GLOBAL:
+		return "AST_GLOBAL"
+	case AST_UNSET:
+		return "AST_UNSET"
+	case AST_RETURN:
+		return "AST_RETURN"
+	case AST_LABEL:
+		return "AST_LABEL"
+	case AST_REF:
+		return "AST_REF"
+	case AST_HALT_COMPILER:
+		return "AST_HALT_COMPILER"
+	case AST_ECHO:
+		return "AST_ECHO"
+	case AST_THROW:
+		return "AST_THROW"
+	case AST_GOTO:
+		return "AST_GOTO"
+	case AST_BREAK:
+		return "AST_BREAK"
+	case AST_CONTINUE:
+		return "AST_CONTINUE"
+	case AST_CLOSURE_VAR:
+		return "AST_CLOSURE_VAR"
+	case AST_NULLABLE_TYPE:
+		return "AST_NULLABLE_TYPE"
+	case AST_NAME:
+		return "AST_NAME"
+	case AST_DIM:
+		return "AST_DIM"
+	case AST_PROP:
+		return "AST_PROP"
+	case AST_NULLSAFE_PROP:
+		return "AST_NULLSAFE_PROP"
+	case AST_STATIC_PROP:
+		return "AST_STATIC_PROP"
+	case AST_CALL:
+		return "AST_CALL"
+	case AST_CLASS_CONST:
+		return "AST_CLASS_CONST"
+	case AST_ASSIGN:
+		return "AST_ASSIGN"
+	case AST_ASSIGN_REF:
+		return "AST_ASSIGN_REF"
+	case AST_ASSIGN_OP:
+		return "AST_ASSIGN_OP"
+	case AST_BINARY_OP:
+		return "AST_BINARY_OP"
+	case AST_ARRAY_ELEM:
+		return "AST_ARRAY_ELEM"
+	case AST_NEW:
+		return "AST_NEW"
+	case AST_INSTANCEOF:
+		return "AST_INSTANCEOF"
+	case AST_YIELD:
+		return "AST_YIELD"
+	case AST_COALESCE:
+		return "AST_COALESCE"
+	case AST_ASSIGN_COALESCE:
+		return "AST_ASSIGN_COALESCE"
+	case AST_STATIC:
+		return "AST_STATIC"
+	case AST_WHILE:
+		return "AST_WHILE"
+	case AST_DO_WHILE:
+		return "AST_DO_WHILE"
+	case AST_IF_ELEM:
+		return "AST_IF_ELEM"
+	case AST_SWITCH_CASE:
+		return "AST_SWITCH_CASE"
+	case AST_CATCH:
+		return "AST_CATCH"
+	case AST_PARAM:
+		return "AST_PARAM"
+	case AST_TYPE_UNION:
+		return "AST_TYPE_UNION"
+	case AST_TYPE_INTERSECTION:
+		return "AST_TYPE_INTERSECTION"
+	case AST_ATTRIBUTE_GROUP:
+		return "AST_ATTRIBUTE_GROUP"
+	case AST_MATCH_ARM:
+		return "AST_MATCH_ARM"
+	case AST_ENUM_CASE:
+		return "AST_ENUM_CASE"
+	case AST_PROPERTY_HOOK:
+		return "AST_PROPERTY_HOOK"
+	case AST_METHOD_CALL:
+		return "AST_METHOD_CALL"
+	case AST_NULLSAFE_METHOD_CALL:
+		return "AST_NULLSAFE_METHOD_CALL"
+	case AST_STATIC_CALL:
+		return "AST_STATIC_CALL"
+	case AST_CONDITIONAL:
+		return "AST_CONDITIONAL"
+	case AST_TRY:
+		return "AST_TRY"
+	case AST_FOREACH:
+		return "AST_FOREACH"
+	case AST_DECLARE:
+		return "AST_DECLARE"
+	case AST_FOR:
+		return "AST_FOR"
+	case AST_SWITCH:
+		return "AST_SWITCH"
+	case AST_PROP_ELEM:
+		return "AST_PROP_ELEM"
+	case AST_CONST_ELEM:
+		return "AST_CONST_ELEM"
+	case AST_USE_TRAIT:
+		return "AST_USE_TRAIT"
+	case AST_TRAIT_PRECEDENCE:
+		return "AST_TRAIT_PRECEDENCE"
+	case AST_METHOD_REFERENCE:
+		return "AST_METHOD_REFERENCE"
+	case AST_NAMESPACE:
+		return "AST_NAMESPACE"
+	case AST_USE_ELEM:
+		return "AST_USE_ELEM"
+	case AST_TRAIT_ALIAS:
+		return "AST_TRAIT_ALIAS"
+	case AST_GROUP_USE:
+		return "AST_GROUP_USE"
+	case AST_CLASS_NAME:
+		return "AST_CLASS_NAME"
+	default:
+		return fmt.Sprintf("UNKNOWN_AST_KIND_%d", int(k))
+	}
+}
+
+// kindChildCountSlow是getChildCount()的参照实现：返回k期望的子节点
+// 数量，-1表示可变长度或需要专门处理。只在构建kindDescriptorTable时
+// 调用一次
+func kindChildCountSlow(k ASTKind) int {
+	switch {
+	// 内部专用特殊节点
+	case k == AST_ZVAL || k == AST_ZNODE:
+		return -1
+
+	// 0个子节点的叶子节点
+	case k >= 0 && k <= 4:
+		return 0
+
+	// 声明节点 - 各有不同的子节点数
+	case k >= 64 && k <= 73:
+		switch k {
+		case AST_FUNC_DECL:
+			return 5 // name, params, uses, stmts, return_type
+		case AST_CLOSURE:
+			return 5 // name, params, uses, stmts, return_type
+		case AST_METHOD:
+			return 6 // flags, name, params, return_type, stmts, doc_comment
+		case AST_CLASS:
+			return 5 // flags, name, extends, implements, stmts
+		case AST_ARROW_FUNC:
+			return 4 // params, return_type, expr, static
+		case AST_ENUM:
+			return 5 // flags, name, type, implements, stmts
+		}
+		return -1
+
+	// list()解构赋值目标列表 - 可变长度，但编号落在常规列表区间之外
+	case k == AST_LIST:
+		return -1
+
+	// 列表节点 - 可变长度
+	case k >= 128 && k <= 149:
+		return -1
+
+	// 1个子节点
+	case k >= 320 && k <= 351:
+		return 1
+	case k == AST_CLOSURE_VAR || k == AST_NULLABLE_TYPE:
+		return 1
+
+	// 0个子节点(补充区间)
+	case k == AST_NAME:
+		return 0
+
+	// 2个子节点
+	case k >= 384 && k <= 415:
+		return 2
+
+	// 3个子节点
+	case k >= 448 && k <= 463:
+		return 3
+
+	// 4个子节点
+	case k >= 512 && k <= 517:
+		return 4
+
+	// 声明元素节点 - 各有不同的子节点数
+	case k >= 768 && k <= 777:
+		switch k {
+		case AST_PROP_ELEM:
+			return 2 // name, default
+		case AST_CONST_ELEM:
+			return 2 // name, value
+		case AST_USE_TRAIT:
+			return 2 // name, adaptations
+		case AST_TRAIT_PRECEDENCE:
+			return 2 // method, insteadof
+		case AST_METHOD_REFERENCE:
+			return 2 // class, method
+		case AST_NAMESPACE:
+			return 2 // name, stmts
+		case AST_USE_ELEM:
+			return 2 // name, alias
+		case AST_TRAIT_ALIAS:
+			return 3 // method, alias, modifiers
+		case AST_GROUP_USE:
+			return 2 // prefix, uses
+		case AST_CLASS_NAME:
+			return 1 // name
+		}
+		return -1
+
+	default:
+		return -1 // 未知类型
+	}
+}
+
+// String 返回AST节点类型的字符串表示，O(1)查kindDescriptorTable；
+// 表里没有的kind(理论上不应该出现，allASTKinds没登记的新kind会被
+// kindflags_test.go捕获)返回"UNKNOWN_AST_KIND_%d"
+func (k ASTKind) String() string {
+	if d, ok := kindDescriptorTable[k]; ok {
+		return d.name
+	}
+	return fmt.Sprintf("UNKNOWN_AST_KIND_%d", int(k))
+}
+
+// getChildCount 根据AST节点类型返回期望的子节点数量，-1表示可变长度
+// 或需要专门处理(而不是简单地按固定位置读取子节点)，O(1)查
+// kindDescriptorTable
+func (k ASTKind) getChildCount() int {
+	if d, ok := kindDescriptorTable[k]; ok {
+		return d.childCount
+	}
+	return -1
+}
+
+// Categories 返回k的分类位掩码，O(1)查kindDescriptorTable，表里没有
+// 的kind返回零值(不属于任何类别)。外部调用方可以直接用它做批量分类
+// 判断(比如`k.Categories().Has(ast.CategoryExpression|ast.CategoryStatement)`)，
+// 不需要本包暴露五个单独的IsXxx()方法才能查
+func (k ASTKind) Categories() KindCategory {
+	if d, ok := kindDescriptorTable[k]; ok {
+		return d.categories
+	}
+	return 0
+}
+
+// IsSpecial 检查是否为需要专门处理的特殊节点
+func (k ASTKind) IsSpecial() bool {
+	return k.Categories().Has(CategorySpecial)
+}
+
+// IsList 检查是否为列表节点(含落在常规区间之外的AST_LIST)
+func (k ASTKind) IsList() bool {
+	return k.Categories().Has(CategoryList)
+}
+
+// IsExpression 检查是否为表达式节点
+func (k ASTKind) IsExpression() bool {
+	return k.Categories().Has(CategoryExpression)
+}
+
+// IsStatement 检查是否为语句节点
+func (k ASTKind) IsStatement() bool {
+	return k.Categories().Has(CategoryStatement)
+}
+
+// IsDeclaration 检查是否为声明节点
+func (k ASTKind) IsDeclaration() bool {
+	return k.Categories().Has(CategoryDeclaration)
+}
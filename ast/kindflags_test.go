@@ -0,0 +1,100 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestAllDeclaredKindsHaveDescriptors穷举kind.go里声明的每一个
+// ASTKind常量(通过allASTKinds)，断言kindDescriptorTable里都有它的
+// 条目、名字不是回退的"UNKNOWN_AST_KIND_%d"，并且String()/
+// getChildCount()/Categories()三个查表方法都和各自的*Slow参照实现
+// 一致。新增一个ASTKind常量却忘了加进allASTKinds，或者加进去了但
+// 漏标了某个分类，都会在这里直接测试失败，而不是等到某次String()/
+// getChildCount()调用默默表现成"未知kind"才被发现
+func TestAllDeclaredKindsHaveDescriptors(t *testing.T) {
+	seen := make(map[ASTKind]bool, len(allASTKinds))
+
+	for _, k := range allASTKinds {
+		if seen[k] {
+			t.Errorf("%s (%d) appears more than once in allASTKinds", kindNameSlow(k), int(k))
+		}
+		seen[k] = true
+
+		d, ok := kindDescriptorTable[k]
+		if !ok {
+			t.Errorf("%s (%d) has no entry in kindDescriptorTable", kindNameSlow(k), int(k))
+			continue
+		}
+
+		if d.name == "" || strings.HasPrefix(d.name, "UNKNOWN_AST_KIND_") {
+			t.Errorf("%s (%d) has no real name registered, got %q", kindNameSlow(k), int(k), d.name)
+		}
+		if got, want := k.String(), kindNameSlow(k); got != want {
+			t.Errorf("%s: String() = %q, want %q", kindNameSlow(k), got, want)
+		}
+		if got, want := k.getChildCount(), kindChildCountSlow(k); got != want {
+			t.Errorf("%s: getChildCount() = %d, want %d", kindNameSlow(k), got, want)
+		}
+		if got, want := k.Categories(), kindCategoriesSlow(k); got != want {
+			t.Errorf("%s: Categories() = %v, want %v", kindNameSlow(k), got, want)
+		}
+	}
+}
+
+func TestKindCategoryHas(t *testing.T) {
+	c := CategoryExpression | CategoryStatement
+	if !c.Has(CategoryExpression) {
+		t.Errorf("expected CategoryExpression to be set")
+	}
+	if !c.Has(CategoryStatement) {
+		t.Errorf("expected CategoryStatement to be set")
+	}
+	if c.Has(CategoryList) {
+		t.Errorf("expected CategoryList to be unset")
+	}
+}
+
+func TestKindCategoriesMatchesIsXxxMethods(t *testing.T) {
+	samples := []ASTKind{
+		AST_ZVAL, AST_ZNODE, AST_MAGIC_CONST, AST_CONSTANT, AST_CALLABLE_CONVERT,
+		AST_FUNC_DECL, AST_CLOSURE, AST_CLASS, AST_ENUM,
+		AST_STMT_LIST, AST_LIST, AST_ARRAY,
+		AST_VAR, AST_NAME, AST_NULLABLE_TYPE,
+		AST_BINARY_OP, AST_METHOD_CALL, AST_FOR,
+		AST_PROP_ELEM, AST_CONST_ELEM,
+	}
+
+	for _, k := range samples {
+		cat := k.Categories()
+		if got, want := k.IsSpecial(), cat.Has(CategorySpecial); got != want {
+			t.Errorf("%s: IsSpecial() = %v, want %v", k, got, want)
+		}
+		if got, want := k.IsList(), cat.Has(CategoryList); got != want {
+			t.Errorf("%s: IsList() = %v, want %v", k, got, want)
+		}
+		if got, want := k.IsExpression(), cat.Has(CategoryExpression); got != want {
+			t.Errorf("%s: IsExpression() = %v, want %v", k, got, want)
+		}
+		if got, want := k.IsStatement(), cat.Has(CategoryStatement); got != want {
+			t.Errorf("%s: IsStatement() = %v, want %v", k, got, want)
+		}
+		if got, want := k.IsDeclaration(), cat.Has(CategoryDeclaration); got != want {
+			t.Errorf("%s: IsDeclaration() = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestKindFlagsOutOfRangeIsZero(t *testing.T) {
+	outOfRange := ASTKind(100000)
+	if outOfRange.IsSpecial() || outOfRange.IsList() || outOfRange.IsExpression() ||
+		outOfRange.IsStatement() || outOfRange.IsDeclaration() {
+		t.Errorf("expected out-of-range kind to classify as nothing")
+	}
+	if outOfRange.getChildCount() != -1 {
+		t.Errorf("expected out-of-range kind to report childCount -1, got %d", outOfRange.getChildCount())
+	}
+	if outOfRange.String() == "" {
+		t.Errorf("expected out-of-range kind to still produce a non-empty fallback string")
+	}
+}
@@ -0,0 +1,56 @@
+package ast
+
+import "strings"
+
+// Logger是一个最小化的结构化日志接口，形状对齐业界常见的结构化
+// logger(lgr、zap的SugaredLogger、slog包一层之后的习惯用法)，这样
+// 调用方可以用一个薄适配器把自己已有的logger接进来，而不需要本包
+// 对具体的日志库产生依赖。每个方法接受printf风格的格式串+参数，
+// 级别由方法名区分，过滤/路由交给Logger的具体实现决定。
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NopLogger是Logger的默认实现，四个方法都什么也不做。Parser/
+// WalkWithLogger在调用方没有显式设置logger时用它兜底，这样内部代码
+// 不需要到处判空
+type NopLogger struct{}
+
+func (NopLogger) Debugf(string, ...interface{}) {}
+func (NopLogger) Infof(string, ...interface{})  {}
+func (NopLogger) Warnf(string, ...interface{})  {}
+func (NopLogger) Errorf(string, ...interface{}) {}
+
+// WalkWithLogger和Walk语义一致(先序、深度优先地对每个节点调用
+// visit)，额外地在访问每个节点之前用log.Debugf记录kind、position和
+// 从root到当前节点的父kind链，方便把解析器嵌入language server或CI
+// linter时排查"这棵树到底长什么样"，不需要依赖Print()的stdout输出。
+// 父链通过ancestorKinds参数线性传递，不依赖BaseNode.Parent/
+// ComputeParents——调用方此时很可能还没跑过ComputeParents。
+func WalkWithLogger(root Node, visit func(Node), log Logger) {
+	if log == nil {
+		log = NopLogger{}
+	}
+	walkWithLogger(root, nil, visit, log)
+}
+
+func walkWithLogger(n Node, ancestorKinds []string, visit func(Node), log Logger) {
+	if n == nil {
+		return
+	}
+
+	chain := "<root>"
+	if len(ancestorKinds) > 0 {
+		chain = strings.Join(ancestorKinds, ">")
+	}
+	log.Debugf("visit kind=%s position=%s parents=%s", n.GetKind().String(), n.GetPosition().String(), chain)
+	visit(n)
+
+	path := append(append([]string{}, ancestorKinds...), n.GetKind().String())
+	for _, c := range n.GetChildren() {
+		walkWithLogger(c, path, visit, log)
+	}
+}
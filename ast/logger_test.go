@@ -0,0 +1,45 @@
+package ast
+
+import "testing"
+
+type recordingLogger struct {
+	debugs []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.debugs = append(l.debugs, format)
+}
+func (*recordingLogger) Infof(string, ...interface{})  {}
+func (*recordingLogger) Warnf(string, ...interface{})  {}
+func (*recordingLogger) Errorf(string, ...interface{}) {}
+
+func TestWalkWithLoggerVisitsEveryNode(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	bin := NewBinaryNode(AST_BINARY_OP, "+", NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos), pos)
+
+	var visited int
+	log := &recordingLogger{}
+	WalkWithLogger(bin, func(Node) { visited++ }, log)
+
+	if visited != 3 {
+		t.Errorf("expected 3 nodes visited, got %d", visited)
+	}
+	if len(log.debugs) != 3 {
+		t.Errorf("expected 3 debug log lines, got %d", len(log.debugs))
+	}
+}
+
+func TestWalkWithLoggerNilLoggerDoesNotPanic(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	lit := NewIntegerLiteral(1, pos)
+
+	WalkWithLogger(lit, func(Node) {}, nil)
+}
+
+func TestNopLoggerImplementsLogger(t *testing.T) {
+	var log Logger = NopLogger{}
+	log.Debugf("x")
+	log.Infof("x")
+	log.Warnf("x")
+	log.Errorf("x")
+}
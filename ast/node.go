@@ -31,9 +31,20 @@ type Node interface {
 	
 	// Accept 访问者模式
 	Accept(visitor Visitor) interface{}
-	
+
 	// String 返回节点的字符串表示
 	String() string
+
+	// GetParent 返回该节点的父节点，根节点或尚未跑过ComputeParents
+	// 的树返回nil
+	GetParent() Node
+
+	// GetTrivia 返回节点的格式还原信息(注释/空白/原始文本)，没有
+	// 采集过trivia的节点返回nil
+	GetTrivia() *Trivia
+
+	// SetTrivia 设置节点的格式还原信息
+	SetTrivia(t *Trivia)
 }
 
 // Visitor 访问者接口
@@ -46,6 +57,8 @@ type BaseNode struct {
 	Kind     ASTKind  `json:"kind"`
 	Position Position `json:"position"`
 	Children []Node   `json:"children,omitempty"`
+	Parent   Node     `json:"-"`              // 由ComputeParents填充，避免JSON序列化时出现环
+	Trivia   *Trivia  `json:"trivia,omitempty"` // 由词法分析阶段按需填充，详见Trivia的注释
 }
 
 // GetKind 返回节点类型
@@ -68,6 +81,28 @@ func (b *BaseNode) Accept(visitor Visitor) interface{} {
 	return visitor.Visit(b)
 }
 
+// GetParent 返回父节点，根节点或尚未计算过父指针时为nil
+func (b *BaseNode) GetParent() Node {
+	return b.Parent
+}
+
+// setParent 由ComputeParents调用，写入父指针；未导出是因为只有
+// 遍历驱动器本身需要修改它，外部代码应当只读GetParent()
+func (b *BaseNode) setParent(p Node) {
+	b.Parent = p
+}
+
+// GetTrivia 返回节点的格式还原信息，没有采集过的节点为nil
+func (b *BaseNode) GetTrivia() *Trivia {
+	return b.Trivia
+}
+
+// SetTrivia 写入节点的格式还原信息，通常由词法分析阶段或者移植
+// 旧格式的codemod工具调用
+func (b *BaseNode) SetTrivia(t *Trivia) {
+	b.Trivia = t
+}
+
 // String 返回基础的字符串表示
 func (b *BaseNode) String() string {
 	return fmt.Sprintf("%s@%s", b.Kind.String(), b.Position.String())
@@ -410,7 +445,7 @@ type IdentifierNode struct {
 
 func NewIdentifier(name string, pos Position) *IdentifierNode {
 	return &IdentifierNode{
-		BaseNode: BaseNode{Kind: AST_CONSTANT, Position: pos}, // 标识符作为常量处理
+		BaseNode: BaseNode{Kind: AST_IDENTIFIER, Position: pos},
 		Name:     name,
 	}
 }
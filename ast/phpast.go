@@ -0,0 +1,369 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DumpPHPAst 把node递归转换成与PHP `ast\parse_code()`输出同构的通用
+// 结构：{kind, flags, lineno, children}。children对叶子标量节点
+// (AST_ZVAL)直接是原始值本身；对固定元数的kind(AST_VAR/AST_ASSIGN/
+// AST_BINARY_OP等)是按真实php-ast用的具名map(phpASTShape决定用哪些
+// 键、按什么顺序)——真实消费者(Phan之类)是按名字取子节点的，不是按
+// 下标；只有php-ast自己也表示成位置数组的"列表"kind(AST_STMT_LIST/
+// AST_ARG_LIST/AST_ARRAY/AST_IF等)才继续用位置数组。flags由
+// phpASTFlags从已有的Operator/Op字符串字段翻译成php-ast flags.md里
+// 的数值编码，见该函数和它用到的几张表上的说明。
+func DumpPHPAst(n Node) interface{} {
+	if n == nil {
+		return nil
+	}
+
+	if z, ok := n.(*ZvalNode); ok {
+		return z.Value
+	}
+
+	keys, isList := phpASTShape(n)
+
+	var children interface{}
+	if isList {
+		children = phpASTDumpList(n.GetChildren())
+	} else {
+		children = phpASTNamedChildren(n, keys)
+	}
+
+	return map[string]interface{}{
+		"kind":     int(n.GetKind()),
+		"flags":    phpASTFlags(n),
+		"lineno":   n.GetPosition().Line,
+		"children": children,
+	}
+}
+
+// phpASTShape决定n应该编码成具名children(返回按php-ast约定顺序排列
+// 的键名)还是位置数组(isList=true)。encode时n是真正的节点实例；
+// decode时没有具体类型，从nodeRegistry按kind查到factory()产出的同一
+// 个Go类型的零值实例，传进来调用同一个函数——两边用的是同一张表，不
+// 会各写一份、慢慢漂移开。default分支(任何未显式列出的类型，包括
+// *ListNode/*IfNode这些php-ast自己也是列表的kind)都按位置数组处理。
+func phpASTShape(n Node) (keys []string, isList bool) {
+	switch n.(type) {
+	case *ConstantNode, *IdentifierNode, *VariableNode:
+		return []string{"name"}, false
+	case *UnaryNode:
+		return []string{"expr"}, false
+	case *BinaryNode:
+		return []string{"left", "right"}, false
+	case *AssignNode:
+		return []string{"var", "expr"}, false
+	case *TernaryNode:
+		return []string{"cond", "true", "false"}, false
+	case *CallNode:
+		return []string{"expr", "args"}, false
+	case *MethodCallNode:
+		return []string{"expr", "method", "args"}, false
+	case *PropertyNode:
+		return []string{"expr", "prop"}, false
+	case *ArrayAccessNode:
+		return []string{"expr", "dim"}, false
+	case *IfElementNode:
+		return []string{"cond", "stmts"}, false
+	case *WhileNode:
+		return []string{"cond", "stmts"}, false
+	case *ForNode:
+		return []string{"init", "cond", "loop", "stmts"}, false
+	default:
+		return nil, true
+	}
+}
+
+func phpASTDumpList(nodes []Node) []interface{} {
+	out := make([]interface{}, len(nodes))
+	for i, c := range nodes {
+		out[i] = DumpPHPAst(c)
+	}
+	return out
+}
+
+// phpASTNamedChildren按phpASTShape选中的具体类型，把每个字段按
+// php-ast的键名递归dump成一个map。args字段额外包一层AST_ARG_LIST
+// 节点，因为真实php-ast里函数/方法调用的实参本身就是一个子node，不
+// 是裸数组。
+func phpASTNamedChildren(n Node, keys []string) map[string]interface{} {
+	out := make(map[string]interface{}, len(keys))
+	switch node := n.(type) {
+	case *ConstantNode:
+		out["name"] = node.Name
+	case *IdentifierNode:
+		out["name"] = node.Name
+	case *VariableNode:
+		out["name"] = DumpPHPAst(node.Name)
+	case *UnaryNode:
+		out["expr"] = DumpPHPAst(node.Operand)
+	case *BinaryNode:
+		out["left"] = DumpPHPAst(node.Left)
+		out["right"] = DumpPHPAst(node.Right)
+	case *AssignNode:
+		out["var"] = DumpPHPAst(node.Left)
+		out["expr"] = DumpPHPAst(node.Right)
+	case *TernaryNode:
+		out["cond"] = DumpPHPAst(node.Condition)
+		out["true"] = DumpPHPAst(node.TrueExpr)
+		out["false"] = DumpPHPAst(node.FalseExpr)
+	case *CallNode:
+		out["expr"] = DumpPHPAst(node.Callee)
+		out["args"] = phpASTArgList(node.Arguments, node.GetPosition())
+	case *MethodCallNode:
+		out["expr"] = DumpPHPAst(node.Object)
+		out["method"] = DumpPHPAst(node.Method)
+		out["args"] = phpASTArgList(node.Arguments, node.GetPosition())
+	case *PropertyNode:
+		out["expr"] = DumpPHPAst(node.Object)
+		out["prop"] = DumpPHPAst(node.Property)
+	case *ArrayAccessNode:
+		out["expr"] = DumpPHPAst(node.Array)
+		out["dim"] = DumpPHPAst(node.Index)
+	case *IfElementNode:
+		out["cond"] = DumpPHPAst(node.Condition)
+		out["stmts"] = DumpPHPAst(node.Body)
+	case *WhileNode:
+		out["cond"] = DumpPHPAst(node.Condition)
+		out["stmts"] = DumpPHPAst(node.Body)
+	case *ForNode:
+		out["init"] = DumpPHPAst(node.Init)
+		out["cond"] = DumpPHPAst(node.Condition)
+		out["loop"] = DumpPHPAst(node.Update)
+		out["stmts"] = DumpPHPAst(node.Body)
+	}
+	return out
+}
+
+func phpASTArgList(args []Node, pos Position) map[string]interface{} {
+	return map[string]interface{}{
+		"kind":     int(AST_ARG_LIST),
+		"flags":    0,
+		"lineno":   pos.Line,
+		"children": phpASTDumpList(args),
+	}
+}
+
+// phpASTFlags把已有节点身上以字符串形式保留的运算符/类型信息翻译成
+// php-ast真正用的数值flags。没有对应字符串可翻译的节点(多数kind)保
+// 持flags=0，这本身也是php-ast里的合法值。
+func phpASTFlags(n Node) int {
+	switch node := n.(type) {
+	case *BinaryNode:
+		return phpASTBinaryFlags[node.Operator]
+	case *AssignNode:
+		return phpASTAssignOpFlag(node.Op)
+	case *UnaryNode:
+		switch node.GetKind() {
+		case AST_CAST:
+			return phpASTCastFlags[node.Operator]
+		case AST_INCLUDE_OR_EVAL:
+			return phpASTIncludeFlags[node.Operator]
+		}
+	}
+	return 0
+}
+
+func phpASTAssignOpFlag(op string) int {
+	if op == "" {
+		return 0
+	}
+	return phpASTBinaryFlags[strings.TrimSuffix(op, "=")]
+}
+
+// phpASTBinaryFlags把BinaryNode.Operator、或去掉复合赋值末尾"="后的
+// AssignNode.Op，翻译成php-ast flags.md文档里AST_BINARY_OP和
+// AST_ASSIGN_OP共用的数值编码。这几个数字是凭记忆抄自公开文档，这个
+// 沙箱里没有真正的PHP/php-ast扩展可以逐项核对，使用前建议对照一份
+// 真实php-ast dump验证。
+//
+// 故意没覆盖的运算符：真实php-ast里`>`/`>=`是编译期把操作数交换成
+// `<`/`<=`表示的(不是单纯换一个flags数值，需要重排子节点)，而
+// `&&`/`||`/`xor`在真实php-ast里根本不是带flags的AST_BINARY_OP，是
+// 各自独立的AST_AND/AST_OR kind(本包的BinaryNode目前没有做这种
+// kind级别的区分)。这几个运算符都留在flags=0，没有为了凑数而编造一
+// 个不存在的flags值。
+var phpASTBinaryFlags = map[string]int{
+	"|":   1,
+	"&":   2,
+	"^":   3,
+	".":   4,
+	"+":   5,
+	"-":   6,
+	"*":   7,
+	"/":   8,
+	"%":   9,
+	"**":  10,
+	"<<":  11,
+	">>":  12,
+	"??":  13,
+	"===": 14,
+	"!==": 15,
+	"==":  16,
+	"!=":  17,
+	"<>":  17,
+	"<":   18,
+	"<=":  19,
+}
+
+// phpASTCastFlags把AST_CAST的UnaryNode.Operator翻译成php-ast
+// flags.md里的TYPE_*编码，同样是凭记忆转录、未经实机核对。本包目前
+// 没有任何parser代码路径会产出AST_CAST节点，这里先按PHP强制转换关键
+// 字的常见拼写登记，等parser真正开始解析`(int)`这类表达式、确定
+// Operator字段的实际拼写后再校正。
+var phpASTCastFlags = map[string]int{
+	"int":     1,
+	"integer": 1,
+	"float":   2,
+	"double":  2,
+	"string":  3,
+	"array":   4,
+	"object":  5,
+	"bool":    6,
+	"boolean": 6,
+	"unset":   7,
+}
+
+// phpASTIncludeFlags把AST_INCLUDE_OR_EVAL的UnaryNode.Operator翻译成
+// php-ast flags.md里的EXEC_*编码，同样是凭记忆转录、未经实机核对，
+// 本包也还没有parser代码路径产出这种节点。
+var phpASTIncludeFlags = map[string]int{
+	"eval":         1,
+	"include":      2,
+	"include_once": 3,
+	"require":      4,
+	"require_once": 5,
+}
+
+// MarshalPHPAst 把DumpPHPAst(n)的结果编码成JSON，字节内容与PHP
+// `json_encode(ast\parse_code(...))`的形状兼容，供依赖php-ast输出的
+// 既有流水线(Phan的宽容转换器、自定义linter等)直接读取，把这个Go
+// parser当作子进程跑起来使用。
+func MarshalPHPAst(n Node) ([]byte, error) {
+	return json.Marshal(DumpPHPAst(n))
+}
+
+// RawNode 是UnmarshalPHPAst的产物：承载从php-ast JSON dump读回的
+// 通用节点，供测试阶段和本包自己parser产出的typed节点做跨实现比对。
+// 它实现了Node接口，但不是本包其余typed节点那样的具体类型——标量
+// 叶子的值保存在Scalar里，此时Kind为AST_ZVAL且GetChildren()为空。
+type RawNode struct {
+	BaseNode
+	Flags  int
+	Scalar interface{}
+}
+
+// String 返回RawNode的字符串表示
+func (r *RawNode) String() string {
+	if r.Kind == AST_ZVAL {
+		return fmt.Sprintf("Zval(%v)@%s", r.Scalar, r.Position.String())
+	}
+	return r.BaseNode.String()
+}
+
+// UnmarshalPHPAst 反序列化MarshalPHPAst或真实php-ast扩展产出的JSON，
+// 重建成RawNode树。标量值(数字/字符串/布尔/null)被还原为Kind为
+// AST_ZVAL的叶子RawNode，其Scalar字段持有原始值；其余节点按kind/
+// lineno/children递归重建，children既可能是位置数组也可能是具名map
+// (见decodePHPAstChildren)，两种形状都按出现的样子处理，调用方不需
+// 要提前知道是哪一种。
+func UnmarshalPHPAst(data []byte) (Node, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return decodePHPAst(raw, Position{})
+}
+
+func decodePHPAst(raw interface{}, pos Position) (Node, error) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		// 标量叶子节点(字符串/数字/布尔/nil)，对应php-ast中裸露的值
+		return &RawNode{BaseNode: BaseNode{Kind: AST_ZVAL, Position: pos}, Scalar: raw}, nil
+	}
+
+	kindVal, _ := obj["kind"].(float64)
+	flagsVal, _ := obj["flags"].(float64)
+	linenoVal, _ := obj["lineno"].(float64)
+	kind := ASTKind(int(kindVal))
+	nodePos := Position{Line: int(linenoVal)}
+
+	children, err := decodePHPAstChildren(kind, obj["children"], nodePos)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RawNode{
+		BaseNode: BaseNode{Kind: kind, Position: nodePos, Children: children},
+		Flags:    int(flagsVal),
+	}, nil
+}
+
+// decodePHPAstChildren还原children，按JSON里实际出现的形状分两路：
+// 位置数组([]interface{})直接按下标递归；具名map(map[string]interface{})
+// 按phpASTChildOrder查到的键顺序递归，保证和DumpPHPAst编码时用的是
+// 同一套键名/顺序。查不到kind对应的具名顺序(kind没注册在nodeRegistry
+// 里，或注册的类型本身就是位置数组形状)时退化成按键名字典序，至少不
+// 丢数据，只是顺序可能和原始不一致。
+func decodePHPAstChildren(kind ASTKind, raw interface{}, pos Position) ([]Node, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	switch v := raw.(type) {
+	case []interface{}:
+		children := make([]Node, len(v))
+		for i, rc := range v {
+			child, err := decodePHPAst(rc, pos)
+			if err != nil {
+				return nil, err
+			}
+			children[i] = child
+		}
+		return children, nil
+	case map[string]interface{}:
+		keys, ok := phpASTChildOrder(kind)
+		if !ok {
+			keys = make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+		}
+		children := make([]Node, 0, len(keys))
+		for _, key := range keys {
+			rc, present := v[key]
+			if !present {
+				continue
+			}
+			child, err := decodePHPAst(rc, pos)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		return children, nil
+	default:
+		return nil, fmt.Errorf("ast: unexpected php-ast children shape %T for kind %s", raw, kind.String())
+	}
+}
+
+// phpASTChildOrder从nodeRegistry查出kind对应的Go类型(和DumpPHPAst
+// 编码时看到的是同一个类型)，再用phpASTShape取它的具名键顺序。kind
+// 没注册，或者它的形状本来就是位置数组(isList=true)，都返回ok=false。
+func phpASTChildOrder(kind ASTKind) ([]string, bool) {
+	factory, ok := nodeRegistry[kind]
+	if !ok {
+		return nil, false
+	}
+	keys, isList := phpASTShape(factory())
+	if isList {
+		return nil, false
+	}
+	return keys, true
+}
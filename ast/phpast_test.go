@@ -0,0 +1,138 @@
+package ast
+
+import "testing"
+
+func TestMarshalPHPAstRoundTrip(t *testing.T) {
+	pos := Position{Line: 3, Column: 1}
+	left := NewVariable("x", pos)
+	right := NewIntegerLiteral(42, pos)
+	assign := NewAssignNode(left, right, pos)
+
+	data, err := MarshalPHPAst(assign)
+	if err != nil {
+		t.Fatalf("MarshalPHPAst returned error: %v", err)
+	}
+
+	got, err := UnmarshalPHPAst(data)
+	if err != nil {
+		t.Fatalf("UnmarshalPHPAst returned error: %v", err)
+	}
+
+	raw, ok := got.(*RawNode)
+	if !ok {
+		t.Fatalf("expected *RawNode, got %T", got)
+	}
+	if raw.GetKind() != AST_ASSIGN {
+		t.Errorf("expected kind %v, got %v", AST_ASSIGN, raw.GetKind())
+	}
+	if raw.GetPosition().Line != 3 {
+		t.Errorf("expected lineno 3, got %d", raw.GetPosition().Line)
+	}
+
+	children := raw.GetChildren()
+	if len(children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(children))
+	}
+
+	variable, ok := children[0].(*RawNode)
+	if !ok || variable.GetKind() != AST_VAR {
+		t.Errorf("expected left child kind %v, got %T %v", AST_VAR, children[0], children[0].GetKind())
+	}
+
+	value, ok := children[1].(*RawNode)
+	if !ok {
+		t.Fatalf("expected right child to be *RawNode, got %T", children[1])
+	}
+	if value.GetKind() != AST_ZVAL {
+		t.Errorf("expected int literal kind %v, got %v", AST_ZVAL, value.GetKind())
+	}
+	if n, ok := value.Scalar.(float64); !ok || n != 42 {
+		t.Errorf("expected scalar 42, got %v", value.Scalar)
+	}
+}
+
+func TestDumpPHPAstScalarLeaf(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	lit := NewStringLiteral("hi", pos)
+
+	dumped := DumpPHPAst(lit)
+	if dumped != "hi" {
+		t.Errorf("expected scalar leaf to dump as bare value %q, got %#v", "hi", dumped)
+	}
+}
+
+func TestDumpPHPAstUsesNamedChildrenForFixedArityKinds(t *testing.T) {
+	pos := Position{Line: 5, Column: 1}
+	assign := NewAssignNode(NewVariable("x", pos), NewIntegerLiteral(1, pos), pos)
+
+	dumped, ok := DumpPHPAst(assign).(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map[string]interface{}, got %T", DumpPHPAst(assign))
+	}
+
+	children, ok := dumped["children"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected AST_ASSIGN children to be a named map, got %T", dumped["children"])
+	}
+	if _, ok := children["var"]; !ok {
+		t.Errorf("expected children to have a %q key, got %v", "var", children)
+	}
+	if _, ok := children["expr"]; !ok {
+		t.Errorf("expected children to have a %q key, got %v", "expr", children)
+	}
+}
+
+func TestDumpPHPAstKeepsListKindsPositional(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	stmts := NewStatementList([]Node{NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos)})
+
+	dumped, ok := DumpPHPAst(stmts).(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map[string]interface{}, got %T", DumpPHPAst(stmts))
+	}
+	children, ok := dumped["children"].([]interface{})
+	if !ok || len(children) != 2 {
+		t.Fatalf("expected AST_STMT_LIST children to be a 2-element positional array, got %#v", dumped["children"])
+	}
+}
+
+func TestDumpPHPAstEncodesBinaryOpFlags(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	add := NewBinaryNode(AST_BINARY_OP, "+", NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos), pos)
+
+	dumped, ok := DumpPHPAst(add).(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map[string]interface{}, got %T", DumpPHPAst(add))
+	}
+	if dumped["flags"] != phpASTBinaryFlags["+"] {
+		t.Errorf("expected flags %d for '+', got %v", phpASTBinaryFlags["+"], dumped["flags"])
+	}
+
+	xor := NewBinaryNode(AST_BINARY_OP, "xor", NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos), pos)
+	if dumped := DumpPHPAst(xor).(map[string]interface{}); dumped["flags"] != 0 {
+		t.Errorf("expected flags 0 for an operator with no known php-ast flags code, got %v", dumped["flags"])
+	}
+}
+
+func TestMarshalPHPAstRoundTripsCompoundAssignFlags(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	assign := NewCompoundAssignNode("+=", NewVariable("x", pos), NewIntegerLiteral(1, pos), pos)
+
+	data, err := MarshalPHPAst(assign)
+	if err != nil {
+		t.Fatalf("MarshalPHPAst returned error: %v", err)
+	}
+
+	got, err := UnmarshalPHPAst(data)
+	if err != nil {
+		t.Fatalf("UnmarshalPHPAst returned error: %v", err)
+	}
+
+	raw := got.(*RawNode)
+	if raw.Flags != phpASTBinaryFlags["+"] {
+		t.Errorf("expected decoded flags %d for '+=', got %d", phpASTBinaryFlags["+"], raw.Flags)
+	}
+	if len(raw.GetChildren()) != 2 {
+		t.Fatalf("expected 2 children after round-tripping a named-children kind, got %d", len(raw.GetChildren()))
+	}
+}
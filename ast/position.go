@@ -0,0 +1,139 @@
+package ast
+
+import "fmt"
+
+// EndPosition 估算n在源码中的结束位置。BaseNode目前只记录起始
+// Position，没有单独的长度/结束字段，因此这里用子节点结束位置的
+// 最大值向上归并来近似：没有子节点的叶子按它能读出的文本宽度估算
+// (字面量按打印宽度、标识符/常量名按名字长度)，找不到更精确线索时
+// 退化为起始偏移+1，保证恒有end.Offset > start.Offset。
+func EndPosition(n Node) Position {
+	if n == nil {
+		return Position{}
+	}
+
+	start := n.GetPosition()
+	children := n.GetChildren()
+
+	if len(children) == 0 {
+		length := leafLength(n)
+		return Position{Line: start.Line, Column: start.Column + length, Offset: start.Offset + length}
+	}
+
+	end := start
+	for _, c := range children {
+		if c == nil {
+			continue
+		}
+		if ce := EndPosition(c); ce.Offset > end.Offset {
+			end = ce
+		}
+	}
+
+	if end.Offset <= start.Offset {
+		end.Offset = start.Offset + 1
+		end.Column = start.Column + 1
+	}
+	return end
+}
+
+// leafLength 估算没有子节点的node所占的字符宽度
+func leafLength(n Node) int {
+	switch node := n.(type) {
+	case *ZvalNode:
+		return len(fmt.Sprintf("%v", node.Value))
+	case *ConstantNode:
+		return len(node.Name)
+	case *IdentifierNode:
+		return len(node.Name)
+	default:
+		return 1
+	}
+}
+
+// PathEnclosingInterval 返回从root到"恰好包住[start.Offset,
+// end.Offset)这段字节区间的最深节点"的祖先链(root在前、目标节点在
+// 最后)，做法参照Go工具链astutil.PathEnclosingInterval：从根开始，
+// 每一层都尝试进入第一个span完全覆盖查询区间的子节点，找不到更深
+// 的候选就停在当前层。
+//
+// 零宽区间(end.Offset<=start.Offset)按紧跟在start之后的1字符区间
+// 处理。exact为true当且仅当返回的最深节点的span与查询区间完全重合；
+// 由于这里没有访问原始源码文本，无法像真正的astutil实现那样把
+// "只多覆盖了相邻空白"也算作exact，这是一个已知的简化。
+//
+// Position为零值(未设置，Line/Column/Offset都是0)的节点会被跳过：
+// 不会出现在返回的祖先链里，也不会拿它自己未知的span去做包含判断，
+// 但仍然会尝试深入它的子节点——这类节点通常是程序生成、从没有走过
+// 词法分析阶段赋值Position的合成节点，用它自己的(无效的)Position
+// 去判断"是否包含查询区间"只会产生错误的剪枝。
+func PathEnclosingInterval(root Node, start, end Position) (path []Node, exact bool) {
+	if root == nil {
+		return nil, false
+	}
+	if end.Offset <= start.Offset {
+		end = Position{Line: start.Line, Column: start.Column + 1, Offset: start.Offset + 1}
+	}
+
+	path = pathEnclosing(root, start.Offset, end.Offset)
+	if path == nil {
+		return nil, false
+	}
+
+	tightest := path[len(path)-1]
+	tStart := tightest.GetPosition()
+	tEnd := EndPosition(tightest)
+	exact = tStart.Offset == start.Offset && tEnd.Offset == end.Offset
+	return path, exact
+}
+
+func pathEnclosing(n Node, startOffset, endOffset int) []Node {
+	positioned := hasPosition(n)
+	if positioned {
+		nStart := n.GetPosition()
+		nEnd := EndPosition(n)
+		if nStart.Offset > startOffset || nEnd.Offset < endOffset {
+			return nil
+		}
+	}
+
+	for _, c := range n.GetChildren() {
+		if c == nil {
+			continue
+		}
+		if p := pathEnclosing(c, startOffset, endOffset); p != nil {
+			if !positioned {
+				return p
+			}
+			return append([]Node{n}, p...)
+		}
+	}
+
+	if !positioned {
+		return nil
+	}
+	return []Node{n}
+}
+
+// hasPosition 判断n是否记录了有效的(非零值)Position。未跑过词法
+// 分析、程序生成的合成节点Position通常是零值
+func hasPosition(n Node) bool {
+	p := n.GetPosition()
+	return p.Line != 0 || p.Column != 0 || p.Offset != 0
+}
+
+// NodeAtOffset 返回包住单个字节偏移offset的最深节点，找不到时为nil。
+// 等价于InnermostEnclosing(root, Position{Offset:offset}, 同一偏移)。
+func NodeAtOffset(root Node, offset int) Node {
+	return InnermostEnclosing(root, Position{Offset: offset}, Position{Offset: offset})
+}
+
+// InnermostEnclosing 返回PathEnclosingInterval中祖先链的最后一个
+// 节点(即查询区间的最深包裹节点)，找不到时为nil
+func InnermostEnclosing(root Node, start, end Position) Node {
+	path, _ := PathEnclosingInterval(root, start, end)
+	if len(path) == 0 {
+		return nil
+	}
+	return path[len(path)-1]
+}
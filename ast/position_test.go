@@ -0,0 +1,66 @@
+package ast
+
+import "testing"
+
+func TestPathEnclosingIntervalFindsInnerLiteral(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	one := NewIntegerLiteral(1, Position{Line: 1, Column: 1, Offset: 0})
+	two := NewIntegerLiteral(2, Position{Line: 1, Column: 5, Offset: 4})
+	binary := NewBinaryNode(AST_BINARY_OP, "+", one, two, pos)
+
+	path, exact := PathEnclosingInterval(binary, Position{Offset: 4}, Position{Offset: 5})
+	if len(path) != 2 {
+		t.Fatalf("expected a 2-node path (binary, literal), got %d", len(path))
+	}
+	if path[0] != Node(binary) {
+		t.Errorf("expected path to start at the binary node")
+	}
+	if path[1] != Node(two) {
+		t.Errorf("expected path to end at the second literal")
+	}
+	if !exact {
+		t.Errorf("expected exact match for the literal's own span")
+	}
+}
+
+func TestNodeAtOffsetOutsideTreeReturnsNil(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	lit := NewIntegerLiteral(1, pos)
+
+	if got := NodeAtOffset(lit, 500); got != nil {
+		t.Errorf("expected nil for an offset outside the node's span, got %v", got)
+	}
+}
+
+func TestPathEnclosingIntervalSkipsUnsetPositionWrapper(t *testing.T) {
+	one := NewIntegerLiteral(1, Position{Line: 1, Column: 1, Offset: 0})
+	two := NewIntegerLiteral(2, Position{Line: 1, Column: 5, Offset: 4})
+	binary := NewBinaryNode(AST_BINARY_OP, "+", one, two, Position{Line: 1, Column: 1, Offset: 0})
+	// NewStatementList copies its first statement's Position, so it
+	// can't be used here to get a genuinely zero Position; build the
+	// wrapper directly instead, simulating a synthetic node that was
+	// never assigned a real position.
+	wrapper := &ListNode{BaseNode: BaseNode{Kind: AST_STMT_LIST, Children: []Node{binary}}, Elements: []Node{binary}}
+
+	path, _ := PathEnclosingInterval(wrapper, Position{Offset: 4}, Position{Offset: 5})
+	for _, n := range path {
+		if n == Node(wrapper) {
+			t.Errorf("expected the zero-position wrapper to be skipped, got it in path %v", path)
+		}
+	}
+	if len(path) == 0 || path[len(path)-1] != Node(two) {
+		t.Errorf("expected path to still reach the inner literal despite the unset-position wrapper, got %v", path)
+	}
+}
+
+func TestInnermostEnclosingWholeTree(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	one := NewIntegerLiteral(1, Position{Line: 1, Column: 1, Offset: 0})
+	two := NewIntegerLiteral(2, Position{Line: 1, Column: 5, Offset: 4})
+	binary := NewBinaryNode(AST_BINARY_OP, "+", one, two, pos)
+
+	got := InnermostEnclosing(binary, Position{Offset: 0}, Position{Offset: 5})
+	if got != Node(binary) {
+		t.Errorf("expected the whole binary expression to be innermost enclosing node")
+	}
+}
@@ -0,0 +1,266 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wudi/php-parser/ast"
+)
+
+// Format选择Printer.Print输出的形式。不同的消费者想要不同的verbosity:
+// 测试快照/codemod要能重新解析的PHP源码，调试日志要能塞进一行的
+// 摘要，静态分析流水线往往更愿意grep一棵S表达式形状的树而不是PHP
+// 语法本身。
+type Format int
+
+const (
+	// FormatIndented是默认格式：Fprint/Sprint已经在用的、带缩进的PHP
+	// 源码
+	FormatIndented Format = iota
+	// FormatSExpr是S表达式形式，例如(assign (var x) (zval 42))，适合
+	// diff和grep
+	FormatSExpr
+	// FormatCompact把FormatIndented的输出压成一行，适合写进日志
+	FormatCompact
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatIndented:
+		return "indented"
+	case FormatSExpr:
+		return "sexpr"
+	case FormatCompact:
+		return "compact"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// Printer把Format和Options绑在一起，这样调用方可以把"用什么格式"
+// 当成配置传来传去，而不是在每个调用点重复判断该调哪个包级函数。
+// 包级的Print/PrintSExpr/PrintCompact仍然保留，Printer只是在它们
+// 之上加了一层可插拔的分发
+type Printer struct {
+	Format  Format
+	Options Options
+}
+
+// NewPrinter创建一个使用给定格式和格式选项的Printer。Options只在
+// Format为FormatIndented/FormatCompact时生效，FormatSExpr不关心
+// 缩进/PSR12/尾随逗号这些选项
+func NewPrinter(format Format, opts Options) *Printer {
+	return &Printer{Format: format, Options: opts}
+}
+
+// Print按pr.Format把n渲染成字符串
+func (pr *Printer) Print(n ast.Node) (string, error) {
+	switch pr.Format {
+	case FormatSExpr:
+		return PrintSExpr(n), nil
+	case FormatCompact:
+		return PrintCompact(n, pr.Options)
+	default:
+		return Print(n, pr.Options)
+	}
+}
+
+// PrintSExpr把n渲染成S表达式形式，例如二元表达式1+2*3会打印成
+// "(+ 1 (* 2 3))"，赋值$x = 42打印成"(assign (var x) (zval 42))"。
+// 和Print不同，这里不关心运算符优先级要不要插括号——S表达式本身的
+// 嵌套结构就是消歧义的括号，不需要再额外判断。
+//
+// 还没有专门处理的节点种类退化为"(kind-name)"，不带子节点，保证不
+// 崩溃；等parser产出对应typed节点后再在下面的switch里补上对应分支。
+func PrintSExpr(n ast.Node) string {
+	var b strings.Builder
+	writeSExpr(&b, n)
+	return b.String()
+}
+
+func writeSExpr(b *strings.Builder, n ast.Node) {
+	if n == nil {
+		b.WriteString("nil")
+		return
+	}
+
+	switch node := n.(type) {
+	case *ast.ZvalNode:
+		b.WriteString("(zval ")
+		writeSExprScalar(b, node.Value)
+		b.WriteByte(')')
+
+	case *ast.ConstantNode:
+		fmt.Fprintf(b, "(const %s)", node.Name)
+
+	case *ast.IdentifierNode:
+		fmt.Fprintf(b, "(ident %s)", node.Name)
+
+	case *ast.VariableNode:
+		b.WriteString("(var ")
+		if zval, ok := node.Name.(*ast.ZvalNode); ok {
+			fmt.Fprintf(b, "%v", zval.Value)
+		} else {
+			writeSExpr(b, node.Name)
+		}
+		b.WriteByte(')')
+
+	case *ast.UnaryNode:
+		fmt.Fprintf(b, "(%s ", node.Operator)
+		writeSExpr(b, node.Operand)
+		b.WriteByte(')')
+
+	case *ast.BinaryNode:
+		fmt.Fprintf(b, "(%s ", node.Operator)
+		writeSExpr(b, node.Left)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Right)
+		b.WriteByte(')')
+
+	case *ast.AssignNode:
+		if node.Op != "" {
+			fmt.Fprintf(b, "(assign-op %s ", node.Op)
+		} else {
+			b.WriteString("(assign ")
+		}
+		writeSExpr(b, node.Left)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Right)
+		b.WriteByte(')')
+
+	case *ast.TernaryNode:
+		b.WriteString("(cond ")
+		writeSExpr(b, node.Condition)
+		b.WriteByte(' ')
+		if node.TrueExpr != nil {
+			writeSExpr(b, node.TrueExpr)
+		} else {
+			b.WriteString("nil")
+		}
+		b.WriteByte(' ')
+		writeSExpr(b, node.FalseExpr)
+		b.WriteByte(')')
+
+	case *ast.CallNode:
+		b.WriteString("(call ")
+		writeSExpr(b, node.Callee)
+		for _, arg := range node.Arguments {
+			b.WriteByte(' ')
+			writeSExpr(b, arg)
+		}
+		b.WriteByte(')')
+
+	case *ast.MethodCallNode:
+		tag := "method-call"
+		if node.Nullsafe {
+			tag = "nullsafe-method-call"
+		}
+		fmt.Fprintf(b, "(%s ", tag)
+		writeSExpr(b, node.Object)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Method)
+		for _, arg := range node.Arguments {
+			b.WriteByte(' ')
+			writeSExpr(b, arg)
+		}
+		b.WriteByte(')')
+
+	case *ast.PropertyNode:
+		tag := "prop"
+		if node.Nullsafe {
+			tag = "nullsafe-prop"
+		}
+		fmt.Fprintf(b, "(%s ", tag)
+		writeSExpr(b, node.Object)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Property)
+		b.WriteByte(')')
+
+	case *ast.ArrayAccessNode:
+		b.WriteString("(dim ")
+		writeSExpr(b, node.Array)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Index)
+		b.WriteByte(')')
+
+	case *ast.ListNode:
+		b.WriteString("(list")
+		for _, el := range node.Elements {
+			b.WriteByte(' ')
+			writeSExpr(b, el)
+		}
+		b.WriteByte(')')
+
+	case *ast.IfNode:
+		b.WriteString("(if")
+		for _, el := range node.Elements {
+			b.WriteByte(' ')
+			writeSExpr(b, el)
+		}
+		b.WriteByte(')')
+
+	case *ast.IfElementNode:
+		b.WriteString("(if-elem ")
+		if node.Condition != nil {
+			writeSExpr(b, node.Condition)
+		} else {
+			b.WriteString("else")
+		}
+		b.WriteByte(' ')
+		writeSExpr(b, node.Body)
+		b.WriteByte(')')
+
+	case *ast.WhileNode:
+		b.WriteString("(while ")
+		writeSExpr(b, node.Condition)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Body)
+		b.WriteByte(')')
+
+	case *ast.ForNode:
+		b.WriteString("(for ")
+		writeSExpr(b, node.Init)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Condition)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Update)
+		b.WriteByte(' ')
+		writeSExpr(b, node.Body)
+		b.WriteByte(')')
+
+	default:
+		fmt.Fprintf(b, "(%s)", n.GetKind().String())
+	}
+}
+
+func writeSExprScalar(b *strings.Builder, v interface{}) {
+	switch val := v.(type) {
+	case string:
+		b.WriteByte('"')
+		b.WriteString(strings.ReplaceAll(val, `"`, `\"`))
+		b.WriteByte('"')
+	case bool:
+		if val {
+			b.WriteString("true")
+		} else {
+			b.WriteString("false")
+		}
+	case nil:
+		b.WriteString("null")
+	default:
+		fmt.Fprintf(b, "%v", val)
+	}
+}
+
+// PrintCompact把n按opts渲染成的PHP源码压成一行，适合写日志：换行和
+// 缩进产生的空白全部折叠成单个空格。和ast.PrintCompact(对node.String()
+// 做递归拼接、不关心运算符语义)不一样，这里复用Print已有的、带优先级
+// 括号插入的PHP重生成逻辑，只是最后把空白折叠掉，输出仍然是合法的
+// 单行PHP表达式/语句。
+func PrintCompact(n ast.Node, opts Options) (string, error) {
+	text, err := Print(n, opts)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(text), " "), nil
+}
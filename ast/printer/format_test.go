@@ -0,0 +1,68 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wudi/php-parser/ast"
+)
+
+func TestPrintSExprAssign(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	n := ast.NewAssignNode(ast.NewVariable("x", pos), ast.NewIntegerLiteral(42, pos), pos)
+
+	got := PrintSExpr(n)
+	want := "(assign (var x) (zval 42))"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPrintSExprBinary(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	n := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+
+	got := PrintSExpr(n)
+	want := "(+ (zval 1) (zval 2))"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPrintCompactCollapsesWhitespace(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	n := ast.NewWhileNode(ast.NewVariable("done", pos), ast.NewIntegerLiteral(1, pos), pos)
+
+	got, err := PrintCompact(n, DefaultOptions())
+	if err != nil {
+		t.Fatalf("PrintCompact returned an error: %v", err)
+	}
+	if strings.Contains(got, "\n") {
+		t.Errorf("expected a single-line result, got %q", got)
+	}
+}
+
+func TestPrinterDispatchesByFormat(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	n := ast.NewAssignNode(ast.NewVariable("x", pos), ast.NewIntegerLiteral(42, pos), pos)
+
+	cases := []struct {
+		format Format
+		want   string
+	}{
+		{FormatIndented, "$x = 42"},
+		{FormatSExpr, "(assign (var x) (zval 42))"},
+		{FormatCompact, "$x = 42"},
+	}
+
+	for _, c := range cases {
+		pr := NewPrinter(c.format, DefaultOptions())
+		got, err := pr.Print(n)
+		if err != nil {
+			t.Fatalf("Print with format %s returned an error: %v", c.format, err)
+		}
+		if got != c.want {
+			t.Errorf("format %s: expected %q, got %q", c.format, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// Package printer 把ast.Node重新生成为PHP源码，用作格式化工具和
+// codemod的基础。它覆盖了ast包当前typed node集合能表达的那部分语法
+// (字面量、变量、二元/一元/三元/赋值表达式、函数调用、属性/数组访问、
+// if/while/for控制结构)，对运算符做了基于优先级的括号插入；match、
+// switch、枚举、属性钩子、trait适配、字符串插值等parser目前还没有
+// 产生对应typed节点的语法，遇到时会退化为打印node.String()，以保证
+// 不会崩溃，但输出不一定是合法PHP——等parser补上这些节点后再扩展。
+//
+// 节点如果带有ast.Trivia(词法分析阶段按需采集的注释/空白/原始文本)，
+// PrintPatch能利用它只重新生成"脏"的那部分子树，未改动的部分原样
+// 照抄，这样格式化工具/codemod产生的diff能压到最小。Print/Fprint/
+// Sprint不关心trivia，总是规范化重新生成整棵树。
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/wudi/php-parser/ast"
+)
+
+// Options 控制输出的格式细节
+type Options struct {
+	// Indent 每级缩进使用的字符串，默认四个空格
+	Indent string
+	// PSR12 为true时大括号另起一行(PSR-12风格)；为false时采用
+	// "右大括号跟在同一行"的K&R风格
+	PSR12 bool
+	// TrailingComma 为true时数组/参数列表的最后一个元素后追加逗号
+	TrailingComma bool
+	// ShortTags 为true时开放标签相关输出使用<?=等短标签形式。目前
+	// ast包里还没有表示"开放标签/内联HTML"边界的节点类型，所以这个
+	// 选项暂时不影响任何输出，先占好位置，等parser产出对应节点后再
+	// 接上
+	ShortTags bool
+}
+
+// PrintOptions 是Print/PrintPatch使用的格式选项，和Fprint/Sprint
+// 用的Options是同一套字段——没有必要维护两个几乎一样的struct，所以
+// 这里直接取别名
+type PrintOptions = Options
+
+// DefaultOptions 返回默认格式选项：四空格缩进、K&R风格大括号、
+// 不追加尾随逗号
+func DefaultOptions() Options {
+	return Options{Indent: "    "}
+}
+
+// Fprint 把n格式化为PHP源码写入w
+func Fprint(w io.Writer, n ast.Node, opts Options) error {
+	p := &printer{opts: opts}
+	p.print(n, 0)
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// Sprint 使用DefaultOptions()把n格式化为PHP源码字符串
+func Sprint(n ast.Node) string {
+	var buf bytes.Buffer
+	_ = Fprint(&buf, n, DefaultOptions())
+	return buf.String()
+}
+
+// Print把n格式化为PHP源码并以字符串形式返回。和Sprint的区别是
+// Print接受调用方传入的opts(Sprint固定用DefaultOptions)、并且把
+// Fprint的error透传出来，而不是像Sprint那样直接丢弃——当前的print
+// 实现不会失败，但保留这条路径是为了未来给trivia校验或者别的失败
+// 场景留口子，不需要再改调用方的签名。
+func Print(n ast.Node, opts PrintOptions) (string, error) {
+	var buf bytes.Buffer
+	if err := Fprint(&buf, n, opts); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// PrintPatch是面向格式化工具/codemod的"最小化diff"打印模式：dirty
+// 里标记为true的节点按常规规则重新生成；其余节点如果采集过
+// ast.Trivia且带有RawText，就原样把RawText(连同前后的注释/空白)吐
+// 出来，不做任何重新格式化。dirty为nil时等价于"所有节点都不脏"，也
+// 就是能多大程度保留原始文本就保留多少——配合没跑过trivia采集的
+// 节点(Trivia为nil)会自动退化为常规打印，混合使用两种来源的节点
+// 不会出错，只是混合来源的那部分文本可能和周围重新生成的代码风格
+// 不一致。
+func PrintPatch(n ast.Node, dirty map[ast.Node]bool, opts PrintOptions) (string, error) {
+	p := &printer{opts: opts, patchMode: true, dirty: dirty}
+	p.print(n, 0)
+	return p.buf.String(), nil
+}
+
+type printer struct {
+	buf  bytes.Buffer
+	opts Options
+
+	// patchMode/dirty只被PrintPatch使用：patchMode为true时，print
+	// 在递归到一个"不脏"且带RawText的节点时会原样吐出trivia而不是
+	// 重新生成，见printTrivia
+	patchMode bool
+	dirty     map[ast.Node]bool
+}
+
+// printTrivia原样输出n采集到的格式信息：前置空白、前置注释、原始
+// 文本、后置注释、后置空白，顺序和词法分析阶段采集时的顺序一致
+func (p *printer) printTrivia(t *ast.Trivia) {
+	p.buf.WriteString(t.LeadingSpace)
+	for _, c := range t.LeadingComments {
+		p.buf.WriteString(c)
+	}
+	p.buf.WriteString(t.RawText)
+	for _, c := range t.TrailingComments {
+		p.buf.WriteString(c)
+	}
+	p.buf.WriteString(t.TrailingSpace)
+}
+
+func (p *printer) indent(depth int) {
+	p.buf.WriteString(strings.Repeat(p.opts.Indent, depth))
+}
+
+// precedence 返回二元运算符的优先级，数值越大绑定越紧，用于决定
+// 子表达式是否需要括号。与parser自己的Pratt优先级表是两套独立的
+// 实现——打印器只关心"输出括号后再解析回来得到同一棵树"，不需要
+// 承担parser那边token到precedence的职责
+func precedence(op string) int {
+	switch op {
+	case "or":
+		return 1
+	case "xor":
+		return 2
+	case "and":
+		return 3
+	case "??":
+		return 5
+	case "||":
+		return 6
+	case "&&":
+		return 7
+	case "|":
+		return 8
+	case "^":
+		return 9
+	case "&":
+		return 10
+	case "==", "!=", "===", "!==", "<>", "<=>":
+		return 11
+	case "<", ">", "<=", ">=":
+		return 12
+	case "<<", ">>":
+		return 13
+	case "+", "-", ".":
+		return 14
+	case "*", "/", "%":
+		return 15
+	case "**":
+		return 17
+	default:
+		return 0
+	}
+}
+
+func (p *printer) printExprParenIfLower(n ast.Node, minPrec int) {
+	if bin, ok := n.(*ast.BinaryNode); ok && precedence(bin.Operator) < minPrec {
+		p.buf.WriteByte('(')
+		p.print(n, 0)
+		p.buf.WriteByte(')')
+		return
+	}
+	p.print(n, 0)
+}
+
+func (p *printer) print(n ast.Node, depth int) {
+	if n == nil {
+		return
+	}
+
+	if p.patchMode && !p.dirty[n] {
+		if t := n.GetTrivia(); t != nil && t.RawText != "" {
+			p.printTrivia(t)
+			return
+		}
+	}
+
+	switch node := n.(type) {
+	case *ast.ZvalNode:
+		p.printZval(node.Value)
+
+	case *ast.ConstantNode:
+		p.buf.WriteString(node.Name)
+
+	case *ast.IdentifierNode:
+		p.buf.WriteString(node.Name)
+
+	case *ast.VariableNode:
+		p.buf.WriteByte('$')
+		if name, ok := node.Name.(*ast.ZvalNode); ok {
+			fmt.Fprintf(&p.buf, "%v", name.Value)
+		} else {
+			p.print(node.Name, depth)
+		}
+
+	case *ast.UnaryNode:
+		p.buf.WriteString(node.Operator)
+		p.print(node.Operand, depth)
+
+	case *ast.BinaryNode:
+		prec := precedence(node.Operator)
+		p.printExprParenIfLower(node.Left, prec)
+		fmt.Fprintf(&p.buf, " %s ", node.Operator)
+		p.printExprParenIfLower(node.Right, prec+1)
+
+	case *ast.AssignNode:
+		p.print(node.Left, depth)
+		op := "="
+		if node.Op != "" {
+			op = node.Op
+		}
+		fmt.Fprintf(&p.buf, " %s ", op)
+		p.print(node.Right, depth)
+
+	case *ast.TernaryNode:
+		p.print(node.Condition, depth)
+		p.buf.WriteString(" ? ")
+		if node.TrueExpr != nil {
+			p.print(node.TrueExpr, depth)
+			p.buf.WriteString(" ")
+		}
+		p.buf.WriteString(": ")
+		p.print(node.FalseExpr, depth)
+
+	case *ast.CallNode:
+		p.print(node.Callee, depth)
+		p.printArgs(node.Arguments, depth)
+
+	case *ast.MethodCallNode:
+		p.print(node.Object, depth)
+		if node.Nullsafe {
+			p.buf.WriteString("?->")
+		} else {
+			p.buf.WriteString("->")
+		}
+		p.print(node.Method, depth)
+		p.printArgs(node.Arguments, depth)
+
+	case *ast.PropertyNode:
+		p.print(node.Object, depth)
+		if node.Nullsafe {
+			p.buf.WriteString("?->")
+		} else {
+			p.buf.WriteString("->")
+		}
+		p.print(node.Property, depth)
+
+	case *ast.ArrayAccessNode:
+		p.print(node.Array, depth)
+		p.buf.WriteByte('[')
+		p.print(node.Index, depth)
+		p.buf.WriteByte(']')
+
+	case *ast.ListNode:
+		p.printList(node, depth)
+
+	case *ast.IfNode:
+		p.printIf(node, depth)
+
+	case *ast.WhileNode:
+		p.buf.WriteString("while (")
+		p.print(node.Condition, depth)
+		p.buf.WriteString(") ")
+		p.printBlock(node.Body, depth)
+
+	case *ast.ForNode:
+		p.buf.WriteString("for (")
+		p.print(node.Init, depth)
+		p.buf.WriteString("; ")
+		p.print(node.Condition, depth)
+		p.buf.WriteString("; ")
+		p.print(node.Update, depth)
+		p.buf.WriteString(") ")
+		p.printBlock(node.Body, depth)
+
+	default:
+		// 还没有专门处理的节点种类：退化为String()，保证不崩溃
+		p.buf.WriteString(n.String())
+	}
+}
+
+func (p *printer) printZval(v interface{}) {
+	switch val := v.(type) {
+	case string:
+		p.buf.WriteByte('\'')
+		p.buf.WriteString(strings.ReplaceAll(val, "'", "\\'"))
+		p.buf.WriteByte('\'')
+	case bool:
+		if val {
+			p.buf.WriteString("true")
+		} else {
+			p.buf.WriteString("false")
+		}
+	case nil:
+		p.buf.WriteString("null")
+	default:
+		fmt.Fprintf(&p.buf, "%v", val)
+	}
+}
+
+func (p *printer) printArgs(args []ast.Node, depth int) {
+	p.buf.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			p.buf.WriteString(", ")
+		}
+		p.print(arg, depth)
+	}
+	if p.opts.TrailingComma && len(args) > 0 {
+		p.buf.WriteByte(',')
+	}
+	p.buf.WriteByte(')')
+}
+
+// printList按ListNode的具体Kind决定分隔符：AST_ARRAY打印为短数组
+// 语法[...]，其余(语句列表、表达式列表等)逐条换行输出
+func (p *printer) printList(node *ast.ListNode, depth int) {
+	if node.Kind == ast.AST_ARRAY {
+		p.buf.WriteByte('[')
+		for i, el := range node.Elements {
+			if i > 0 {
+				p.buf.WriteString(", ")
+			}
+			p.print(el, depth)
+		}
+		if p.opts.TrailingComma && len(node.Elements) > 0 {
+			p.buf.WriteByte(',')
+		}
+		p.buf.WriteByte(']')
+		return
+	}
+
+	for i, el := range node.Elements {
+		if i > 0 {
+			p.buf.WriteByte('\n')
+			p.indent(depth)
+		}
+		p.print(el, depth)
+		p.buf.WriteByte(';')
+	}
+}
+
+func (p *printer) printBlock(body ast.Node, depth int) {
+	if p.opts.PSR12 {
+		p.buf.WriteByte('\n')
+		p.indent(depth)
+		p.buf.WriteString("{\n")
+	} else {
+		p.buf.WriteString("{\n")
+	}
+	p.indent(depth + 1)
+	p.print(body, depth+1)
+	p.buf.WriteByte('\n')
+	p.indent(depth)
+	p.buf.WriteByte('}')
+}
+
+func (p *printer) printIf(node *ast.IfNode, depth int) {
+	for i, elRaw := range node.Elements {
+		el, ok := elRaw.(*ast.IfElementNode)
+		if !ok {
+			p.print(elRaw, depth)
+			continue
+		}
+
+		if i == 0 {
+			p.buf.WriteString("if (")
+		} else if el.Condition != nil {
+			p.buf.WriteString(" elseif (")
+		} else {
+			p.buf.WriteString(" else ")
+		}
+		if el.Condition != nil {
+			p.print(el.Condition, depth)
+			p.buf.WriteString(") ")
+		}
+		p.printBlock(el.Body, depth)
+	}
+}
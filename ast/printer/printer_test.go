@@ -0,0 +1,104 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/wudi/php-parser/ast"
+)
+
+func TestSprintBinaryPrecedenceParenthesization(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	// (1 + 2) * 3 should keep its parens; the printer must notice
+	// that "+" binds looser than "*" and re-insert them.
+	inner := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+	outer := ast.NewBinaryNode(ast.AST_BINARY_OP, "*", inner, ast.NewIntegerLiteral(3, pos), pos)
+
+	got := Sprint(outer)
+	want := "(1 + 2) * 3"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSprintBinaryNoParensWhenNotNeeded(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	inner := ast.NewBinaryNode(ast.AST_BINARY_OP, "*", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+	outer := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", inner, ast.NewIntegerLiteral(3, pos), pos)
+
+	got := Sprint(outer)
+	want := "1 * 2 + 3"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSprintArrayLiteral(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	arr := ast.NewArrayLiteral([]ast.Node{ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos)}, pos)
+
+	got := Sprint(arr)
+	want := "[1, 2]"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSprintAssignAndCall(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	call := ast.NewCallNode(ast.NewIdentifier("strlen", pos), []ast.Node{ast.NewStringLiteral("hi", pos)}, pos)
+	assign := ast.NewAssignNode(ast.NewVariable("n", pos), call, pos)
+
+	got := Sprint(assign)
+	want := "$n = strlen('hi')"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPrintReturnsSameResultAsSprint(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	bin := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+
+	got, err := Print(bin, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Print returned error: %v", err)
+	}
+	if want := Sprint(bin); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPrintPatchKeepsRawTextForCleanNodes(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	left := ast.NewIntegerLiteral(1, pos)
+	right := ast.NewIntegerLiteral(2, pos)
+	bin := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", left, right, pos)
+	// simulate a node whose source had unusual (but semantically
+	// irrelevant) spacing around the operator
+	bin.SetTrivia(&ast.Trivia{RawText: "1  +  2"})
+
+	got, err := PrintPatch(bin, nil, DefaultOptions())
+	if err != nil {
+		t.Fatalf("PrintPatch returned error: %v", err)
+	}
+	if want := "1  +  2"; got != want {
+		t.Errorf("expected raw text %q to be preserved, got %q", want, got)
+	}
+}
+
+func TestPrintPatchRegeneratesDirtyNodes(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	left := ast.NewIntegerLiteral(1, pos)
+	right := ast.NewIntegerLiteral(2, pos)
+	bin := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", left, right, pos)
+	bin.SetTrivia(&ast.Trivia{RawText: "1  +  2"})
+
+	dirty := map[ast.Node]bool{bin: true}
+	got, err := PrintPatch(bin, dirty, DefaultOptions())
+	if err != nil {
+		t.Fatalf("PrintPatch returned error: %v", err)
+	}
+	if want := "1 + 2"; got != want {
+		t.Errorf("expected dirty node to be regenerated as %q, got %q", want, got)
+	}
+}
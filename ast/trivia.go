@@ -0,0 +1,19 @@
+package ast
+
+// Trivia 保存对语义没有影响、但是把AST转回源码时需要的信息：紧挨在
+// 节点前后的注释、原始空白，以及(可选的)这个节点在原始源码里的文本
+// 片段。采集trivia是可选的——解析器按需在构造节点之后调用
+// SetTrivia，没有调用过的节点GetTrivia()为nil，ast/printer在这种
+// 情况下退化成规范美化输出，不会报错也不会吐出空内容。
+//
+// RawText非空时表示"这段源码原样照抄即可"，是ast/printer实现忠实
+// 回放(以及PrintPatch最小化diff)的依据；LeadingComments/
+// TrailingComments/LeadingSpace/TrailingSpace在RawText为空、只想
+// 保留周围的注释和缩进风格时单独使用。
+type Trivia struct {
+	LeadingComments  []string
+	TrailingComments []string
+	LeadingSpace     string
+	TrailingSpace    string
+	RawText          string
+}
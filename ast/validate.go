@@ -0,0 +1,65 @@
+package ast
+
+import "fmt"
+
+// Validate遍历以root为根的树，检查一些GetKind()/GetChildren()这层
+// API本身不强制、但构造代码应该始终维持的结构性不变量。返回值是
+// 发现的全部问题，而不是遇到第一个就停——方便codemod/parser改完一
+// 大片树之后一次性看到所有违反的地方，不用改一个跑一次。没有问题
+// 时返回nil。
+//
+// 目前检查的不变量:
+//   - IfNode.Elements里每个元素都是*IfElementNode
+//   - 同一个IfNode里，Condition为nil的else分支(如果存在)必须是
+//     最后一个元素，其后不能再有elseif/if
+//   - AssignNode.Left必须是实现了Lvalue接口的节点
+func Validate(root Node) []error {
+	var errs []error
+
+	Walk(root, func(n Node) {
+		switch node := n.(type) {
+		case *IfNode:
+			errs = append(errs, validateIfNode(node)...)
+		case *AssignNode:
+			if err := validateAssignTarget(node); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+
+	return errs
+}
+
+func validateIfNode(n *IfNode) []error {
+	var errs []error
+	sawElse := false
+
+	for idx, el := range n.Elements {
+		elem, ok := el.(*IfElementNode)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: element %d is %T, want *IfElementNode", n.String(), idx, el))
+			continue
+		}
+
+		if elem.Condition == nil {
+			if sawElse {
+				errs = append(errs, fmt.Errorf("%s: more than one else branch (at element %d)", n.String(), idx))
+			}
+			sawElse = true
+		} else if sawElse {
+			errs = append(errs, fmt.Errorf("%s: elseif at element %d follows the else branch", n.String(), idx))
+		}
+	}
+
+	return errs
+}
+
+func validateAssignTarget(n *AssignNode) error {
+	if n.Left == nil {
+		return fmt.Errorf("%s: Left is nil", n.String())
+	}
+	if _, ok := As[Lvalue](n.Left); !ok {
+		return fmt.Errorf("%s: Left is %T, which is not an Lvalue", n.String(), n.Left)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package ast
+
+import "testing"
+
+func TestValidateAcceptsWellFormedIf(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	cond := NewBinaryNode(AST_BINARY_OP, "==", NewIntegerLiteral(1, pos), NewIntegerLiteral(1, pos), pos)
+	ifNode := NewIfNode([]Node{
+		NewIfElementNode(cond, NewStatementList(nil), pos),
+		NewIfElementNode(nil, NewStatementList(nil), pos),
+	}, pos)
+
+	if errs := Validate(ifNode); len(errs) != 0 {
+		t.Errorf("expected no errors for a well-formed if, got %v", errs)
+	}
+}
+
+func TestValidateRejectsElseNotLast(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	cond := NewBinaryNode(AST_BINARY_OP, "==", NewIntegerLiteral(1, pos), NewIntegerLiteral(1, pos), pos)
+	ifNode := NewIfNode([]Node{
+		NewIfElementNode(nil, NewStatementList(nil), pos),
+		NewIfElementNode(cond, NewStatementList(nil), pos),
+	}, pos)
+
+	errs := Validate(ifNode)
+	if len(errs) == 0 {
+		t.Fatalf("expected an error for an elseif following an else branch")
+	}
+}
+
+func TestValidateRejectsNonIfElementInIf(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	ifNode := NewIfNode([]Node{NewIntegerLiteral(1, pos)}, pos)
+
+	errs := Validate(ifNode)
+	if len(errs) == 0 {
+		t.Fatalf("expected an error when an IfNode element is not *IfElementNode")
+	}
+}
+
+func TestValidateRejectsNonLvalueAssignTarget(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	assign := NewAssignNode(NewIntegerLiteral(1, pos), NewIntegerLiteral(2, pos), pos)
+
+	errs := Validate(assign)
+	if len(errs) == 0 {
+		t.Fatalf("expected an error when AssignNode.Left is not an Lvalue")
+	}
+}
+
+func TestValidateAcceptsVariableAssignTarget(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	assign := NewAssignNode(NewVariable("x", pos), NewIntegerLiteral(2, pos), pos)
+
+	if errs := Validate(assign); len(errs) != 0 {
+		t.Errorf("expected no errors assigning to a variable, got %v", errs)
+	}
+}
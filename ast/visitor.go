@@ -0,0 +1,259 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WalkVisitor 是支持Enter/Leave语义的遍历访问者。Enter在进入一个节点时
+// 被调用，返回的WalkVisitor将用于遍历该节点的子树(通常就是接收者自身)，
+// recurse为false时Traverse不会深入该节点的子节点。Leave在该节点(及其
+// 已遍历完的子节点)处理完毕后被调用。
+//
+// 这比现有的Visitor(只有一个Visit(Node) interface{}方法)更适合需要
+// 感知进入/离开时机的场景，例如格式化输出时插入右括号，或是像
+// Rewriter那样需要在子节点遍历完成后才执行替换。
+type WalkVisitor interface {
+	Enter(n Node) (w WalkVisitor, recurse bool)
+	Leave(n Node)
+}
+
+// Traverse 以深度优先顺序遍历以root为根的树，在每个节点上调用
+// v.Enter/v.Leave。与包级函数Walk不同，Traverse把决定"是否深入子树"
+// 以及"用哪个visitor继续遍历"的权力交给调用方，这对一次性遍历中
+// 混合不同行为的场景(例如进入函数体后切换到另一个visitor)很有用。
+func Traverse(v WalkVisitor, root Node) {
+	if root == nil || v == nil {
+		return
+	}
+
+	next, recurse := v.Enter(root)
+	if recurse && next != nil {
+		for _, child := range root.GetChildren() {
+			Traverse(next, child)
+		}
+	}
+	v.Leave(root)
+}
+
+// Printer 是一个WalkVisitor，它在遍历过程中把AST重新拼接成PHP源码。
+// 目前只覆盖了parser已经能产生的节点种类(字面量、变量、标识符、
+// 二元/赋值表达式等)；遇到尚不认识的节点会退化为打印其String()，
+// 保证遍历不会崩溃，但输出不一定是合法PHP。更完整的反解析见后续
+// 专门的printer子包。
+type Printer struct {
+	buf strings.Builder
+}
+
+// NewPrinter 创建一个空的Printer
+func NewPrinter() *Printer {
+	return &Printer{}
+}
+
+// String 返回目前为止生成的源码
+func (p *Printer) String() string {
+	return p.buf.String()
+}
+
+// Enter 实现WalkVisitor
+func (p *Printer) Enter(n Node) (WalkVisitor, bool) {
+	switch node := n.(type) {
+	case *ZvalNode:
+		fmt.Fprintf(&p.buf, "%v", node.Value)
+		return p, false
+
+	case *VariableNode:
+		p.buf.WriteByte('$')
+		if name, ok := node.Name.(*ZvalNode); ok {
+			fmt.Fprintf(&p.buf, "%v", name.Value)
+		} else {
+			Traverse(p, node.Name)
+		}
+		return p, false
+
+	case *IdentifierNode:
+		p.buf.WriteString(node.Name)
+		return p, false
+
+	case *ConstantNode:
+		p.buf.WriteString(node.Name)
+		return p, false
+
+	case *BinaryNode:
+		Traverse(p, node.Left)
+		fmt.Fprintf(&p.buf, " %s ", node.Operator)
+		Traverse(p, node.Right)
+		return p, false
+
+	case *UnaryNode:
+		p.buf.WriteString(node.Operator)
+		Traverse(p, node.Operand)
+		return p, false
+
+	case *AssignNode:
+		Traverse(p, node.Left)
+		op := "="
+		if node.Op != "" {
+			op = node.Op
+		}
+		fmt.Fprintf(&p.buf, " %s ", op)
+		Traverse(p, node.Right)
+		return p, false
+
+	case *TernaryNode:
+		Traverse(p, node.Condition)
+		p.buf.WriteString(" ? ")
+		if node.TrueExpr != nil {
+			Traverse(p, node.TrueExpr)
+		}
+		p.buf.WriteString(" : ")
+		Traverse(p, node.FalseExpr)
+		return p, false
+
+	case *CallNode:
+		Traverse(p, node.Callee)
+		p.buf.WriteByte('(')
+		for i, arg := range node.Arguments {
+			if i > 0 {
+				p.buf.WriteString(", ")
+			}
+			Traverse(p, arg)
+		}
+		p.buf.WriteByte(')')
+		return p, false
+
+	case *PropertyNode:
+		Traverse(p, node.Object)
+		if node.Nullsafe {
+			p.buf.WriteString("?->")
+		} else {
+			p.buf.WriteString("->")
+		}
+		Traverse(p, node.Property)
+		return p, false
+
+	case *ArrayAccessNode:
+		Traverse(p, node.Array)
+		p.buf.WriteByte('[')
+		Traverse(p, node.Index)
+		p.buf.WriteByte(']')
+		return p, false
+
+	case *ListNode:
+		for i, el := range node.Elements {
+			if i > 0 {
+				p.buf.WriteString(";\n")
+			}
+			Traverse(p, el)
+		}
+		return p, false
+
+	default:
+		p.buf.WriteString(n.String())
+		return p, false
+	}
+}
+
+// Leave 实现WalkVisitor；Printer不需要在离开节点时做任何事
+func (p *Printer) Leave(n Node) {}
+
+// RewriteFn 接收遍历到的节点并返回替换后的节点；返回nil或与入参
+// 相同的节点表示不修改
+type RewriteFn func(n Node) Node
+
+// Rewriter 用于构建代码修改(codemod)工具：Rewrite对一棵树做自底向上
+// (子节点先于父节点)的遍历，每访问到一个节点就调用Fn，如果返回了
+// 不同的节点，就通过setChild把父节点中指向它的引用换成新节点。
+//
+// 替换只对GetChildren()结构"可写"的节点类型生效，即setChild中列出
+// 的类型；其余类型的子节点不会被替换，这与当前BaseNode不提供通用
+// 可写Children切片的限制一致。
+type Rewriter struct {
+	Fn RewriteFn
+}
+
+// NewRewriter 创建一个使用fn重写节点的Rewriter
+func NewRewriter(fn RewriteFn) *Rewriter {
+	return &Rewriter{Fn: fn}
+}
+
+// Rewrite 自底向上遍历root，对每个节点应用Fn，并返回(可能被替换过
+// 的)根节点
+func (r *Rewriter) Rewrite(root Node) Node {
+	if root == nil {
+		return root
+	}
+
+	for i, child := range root.GetChildren() {
+		if newChild := r.Rewrite(child); newChild != nil && newChild != child {
+			setChild(root, i, newChild)
+		}
+	}
+
+	if r.Fn == nil {
+		return root
+	}
+	if replacement := r.Fn(root); replacement != nil {
+		return replacement
+	}
+	return root
+}
+
+// setChild 尝试把parent的第index个子节点替换为child，覆盖了本包
+// 当前定义的、拥有固定数量或可索引子节点的节点类型
+func setChild(parent Node, index int, child Node) {
+	switch p := parent.(type) {
+	case *UnaryNode:
+		if index == 0 {
+			p.Operand = child
+			p.Children[0] = child
+		}
+	case *BinaryNode:
+		switch index {
+		case 0:
+			p.Left = child
+		case 1:
+			p.Right = child
+		}
+		if index < len(p.Children) {
+			p.Children[index] = child
+		}
+	case *AssignNode:
+		switch index {
+		case 0:
+			p.Left = child
+		case 1:
+			p.Right = child
+		}
+		if index < len(p.Children) {
+			p.Children[index] = child
+		}
+	case *ArrayAccessNode:
+		switch index {
+		case 0:
+			p.Array = child
+		case 1:
+			p.Index = child
+		}
+		if index < len(p.Children) {
+			p.Children[index] = child
+		}
+	case *PropertyNode:
+		switch index {
+		case 0:
+			p.Object = child
+		case 1:
+			p.Property = child
+		}
+		if index < len(p.Children) {
+			p.Children[index] = child
+		}
+	case *ListNode:
+		if index >= 0 && index < len(p.Elements) {
+			p.Elements[index] = child
+		}
+		if index < len(p.Children) {
+			p.Children[index] = child
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ast
+
+import "testing"
+
+func TestTraversePrinter(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	left := NewVariable("x", pos)
+	right := NewIntegerLiteral(42, pos)
+	assign := NewAssignNode(left, right, pos)
+
+	printer := NewPrinter()
+	Traverse(printer, assign)
+
+	expected := "$x = 42"
+	if got := printer.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestRewriterReplacesMatchingNode(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	left := NewIntegerLiteral(1, pos)
+	right := NewIntegerLiteral(2, pos)
+	binary := NewBinaryNode(AST_BINARY_OP, "+", left, right, pos)
+
+	rewriter := NewRewriter(func(n Node) Node {
+		if zval, ok := n.(*ZvalNode); ok {
+			if v, ok := zval.Value.(int64); ok {
+				return NewZvalNode(v*10, zval.Position)
+			}
+		}
+		return n
+	})
+
+	rewritten := rewriter.Rewrite(binary).(*BinaryNode)
+
+	gotLeft := rewritten.Left.(*ZvalNode).Value
+	gotRight := rewritten.Right.(*ZvalNode).Value
+	if gotLeft != int64(10) || gotRight != int64(20) {
+		t.Errorf("expected left=10 right=20, got left=%v right=%v", gotLeft, gotRight)
+	}
+
+	children := rewritten.GetChildren()
+	if children[0].(*ZvalNode).Value != int64(10) || children[1].(*ZvalNode).Value != int64(20) {
+		t.Errorf("expected GetChildren() to reflect the rewrite, got %v", children)
+	}
+}
@@ -0,0 +1,130 @@
+package ast
+
+// WalkAction 控制WalkWithParent在某个节点的Enter回调返回后如何继续
+type WalkAction int
+
+const (
+	WalkContinue     WalkAction = iota // 按正常顺序深入当前节点的子节点
+	WalkSkipChildren                   // 不深入子节点，但其余兄弟节点照常遍历
+	WalkStop                           // 立即终止整个遍历
+)
+
+// ParentVisitor 是携带父节点信息、按ASTKind统一调度、支持就地替换
+// 当前节点的访问者。与Traverse使用的WalkVisitor相比，它把"进入节点
+// 时能看到父节点"作为一等公民，更适合需要知道自己在树中位置的pass
+// (作用域分析、按kind做codemod)；命名为ParentVisitor而不是Visitor，
+// 是为了避免和已有的单方法Visitor(BaseNode.Accept使用)冲突。
+type ParentVisitor interface {
+	// Enter在进入n时被调用，parent是n在树中的父节点(根节点为nil)。
+	// 返回的action决定遍历如何继续；如果replacement非nil，驱动器会
+	// 尝试通过setChild把parent中指向n的引用替换为replacement——这个
+	// 替换和Rewriter一样，只对setChild认识的节点类型生效
+	Enter(n Node, parent Node) (action WalkAction, replacement Node)
+	// Leave在n(和它已遍历完的子节点)处理完毕后被调用
+	Leave(n Node)
+}
+
+// WalkWithParent 以深度优先顺序遍历以root为根的树，对每个节点依次
+// 调用v.Enter/v.Leave。遍历本身统一走GetChildren()，因此不需要像
+// 手写switch那样区分128-149的列表节点和固定元数的表达式节点——
+// ListNode.GetChildren()已经返回了正确的可变长度子节点切片。
+func WalkWithParent(root Node, v ParentVisitor) {
+	walkWithParent(root, nil, v)
+}
+
+// walkWithParent 返回false表示visitor请求了WalkStop，调用方需要
+// 向上层传播、不再处理后续兄弟节点
+func walkWithParent(n Node, parent Node, v ParentVisitor) bool {
+	if n == nil || v == nil {
+		return true
+	}
+
+	action, replacement := v.Enter(n, parent)
+	if replacement != nil && replacement != n && parent != nil {
+		if idx := childIndex(parent, n); idx >= 0 {
+			setChild(parent, idx, replacement)
+		}
+		n = replacement
+	}
+
+	switch action {
+	case WalkStop:
+		return false
+	case WalkSkipChildren:
+		v.Leave(n)
+		return true
+	}
+
+	for _, child := range n.GetChildren() {
+		if !walkWithParent(child, n, v) {
+			v.Leave(n)
+			return false
+		}
+	}
+
+	v.Leave(n)
+	return true
+}
+
+// childIndex 在parent.GetChildren()中查找child的位置，找不到返回-1
+func childIndex(parent Node, child Node) int {
+	for i, c := range parent.GetChildren() {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+// ComputeParents 对以root为根的树做一次遍历，把每个节点的父指针
+// 写入其BaseNode.Parent字段(根节点父指针为nil)。命名和实现都对应
+// 真正php-ast扩展里`AST_set_parent_list`式的一次性预处理，跑过之
+// 后下游pass(作用域分析、类型推断)就可以用GetParent()向上查找，
+// 而不需要自己在遍历时维护一个栈。
+func ComputeParents(root Node) {
+	computeParents(root, nil)
+}
+
+func computeParents(n Node, parent Node) {
+	if n == nil {
+		return
+	}
+	if setter, ok := n.(interface{ setParent(Node) }); ok {
+		setter.setParent(parent)
+	}
+	for _, child := range n.GetChildren() {
+		computeParents(child, n)
+	}
+}
+
+// KindVisitor 按ASTKind聚合回调，让调用者为感兴趣的kind各注册一个
+// 函数，而不必写一个覆盖所有kind分支的巨大switch。未注册的kind会
+// 被直接跳过。配合WalkWithParent使用：
+//
+//	kv := NewKindVisitor()
+//	kv.Register(AST_VAR, func(n, parent Node) { ... })
+//	WalkWithParent(root, kv)
+type KindVisitor struct {
+	handlers map[ASTKind]func(n Node, parent Node)
+}
+
+// NewKindVisitor 创建一个空的KindVisitor
+func NewKindVisitor() *KindVisitor {
+	return &KindVisitor{handlers: make(map[ASTKind]func(n Node, parent Node))}
+}
+
+// Register 为kind注册一个回调，同一个kind重复注册会覆盖之前的回调
+func (kv *KindVisitor) Register(kind ASTKind, fn func(n Node, parent Node)) {
+	kv.handlers[kind] = fn
+}
+
+// Enter 实现ParentVisitor；KindVisitor从不替换节点或提前终止遍历
+func (kv *KindVisitor) Enter(n Node, parent Node) (WalkAction, Node) {
+	if fn, ok := kv.handlers[n.GetKind()]; ok {
+		fn(n, parent)
+	}
+	return WalkContinue, nil
+}
+
+// Leave 实现ParentVisitor；KindVisitor不需要在离开节点时做任何事
+func (kv *KindVisitor) Leave(Node) {}
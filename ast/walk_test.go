@@ -0,0 +1,72 @@
+package ast
+
+import "testing"
+
+func TestComputeParentsAttachesParentPointers(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	left := NewIntegerLiteral(1, pos)
+	right := NewIntegerLiteral(2, pos)
+	binary := NewBinaryNode(AST_BINARY_OP, "+", left, right, pos)
+
+	ComputeParents(binary)
+
+	if binary.GetParent() != nil {
+		t.Errorf("expected root parent to be nil, got %v", binary.GetParent())
+	}
+	if left.GetParent() != Node(binary) {
+		t.Errorf("expected left child's parent to be the binary node")
+	}
+	if right.GetParent() != Node(binary) {
+		t.Errorf("expected right child's parent to be the binary node")
+	}
+}
+
+func TestKindVisitorDispatchesRegisteredKinds(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	left := NewVariable("x", pos)
+	right := NewIntegerLiteral(1, pos)
+	assign := NewAssignNode(left, right, pos)
+
+	var seenVars []string
+	kv := NewKindVisitor()
+	kv.Register(AST_VAR, func(n Node, parent Node) {
+		v := n.(*VariableNode)
+		seenVars = append(seenVars, v.Name.(*ZvalNode).Value.(string))
+	})
+
+	WalkWithParent(assign, kv)
+
+	if len(seenVars) != 1 || seenVars[0] != "x" {
+		t.Errorf("expected to visit variable %q once, got %v", "x", seenVars)
+	}
+}
+
+func TestWalkWithParentStopsEarly(t *testing.T) {
+	pos := Position{Line: 1, Column: 1}
+	left := NewIntegerLiteral(1, pos)
+	right := NewIntegerLiteral(2, pos)
+	binary := NewBinaryNode(AST_BINARY_OP, "+", left, right, pos)
+
+	visited := 0
+	v := &stoppingVisitor{stopAfter: 1, visited: &visited}
+	WalkWithParent(binary, v)
+
+	if visited != 1 {
+		t.Errorf("expected traversal to stop after 1 node, visited %d", visited)
+	}
+}
+
+type stoppingVisitor struct {
+	stopAfter int
+	visited   *int
+}
+
+func (v *stoppingVisitor) Enter(n Node, parent Node) (WalkAction, Node) {
+	*v.visited++
+	if *v.visited >= v.stopAfter {
+		return WalkStop, nil
+	}
+	return WalkContinue, nil
+}
+
+func (v *stoppingVisitor) Leave(Node) {}
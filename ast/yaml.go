@@ -0,0 +1,320 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 本仓库没有vendor任何YAML库(没有go.mod、没有third-party依赖)，
+// 所以MarshalYAML/UnmarshalYAML没有走gopkg.in/yaml.v3之类的常规
+// 路线，而是内置了一个只覆盖MarshalNode/UnmarshalNode那份envelope
+// 需要的子集的最小YAML读写器：block风格的mapping/sequence、以及
+// string/number/bool/null标量。不支持anchor/alias、flow风格
+// ({}/[])、多文档(---分隔)、tag(!!foo)等完整YAML规范的其余部分——
+// 如果将来这个子集不够用了，换成真正的YAML库只需要替换这个文件内部
+// 的encodeYAMLValue/decodeYAMLBlock两个函数，MarshalYAML/
+// UnmarshalYAML的签名不变。
+//
+// 两个导出函数都刻意复用MarshalNode/UnmarshalNode已经建立的JSON编解
+// 码：YAML只是JSON这份canonical内部格式之上的一层人类可读的表示，
+// UnmarshalYAML先把YAML解析成和encoding/json相同形状的
+// map[string]interface{}/[]interface{}/标量，再整个重新编码成JSON
+// 字节交给UnmarshalNode，这样两条输入路径(JSON/YAML)实际跑的是
+// 同一份解码逻辑，不会出现"YAML和JSON分别维护一份不一致的schema"。
+func MarshalYAML(n Node) ([]byte, error) {
+	envelope := map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"root":           encodeNode(n),
+	}
+	var b strings.Builder
+	writeYAMLValue(&b, envelope, 0)
+	return []byte(b.String()), nil
+}
+
+// UnmarshalYAML解析MarshalYAML产出的那种YAML文档。和UnmarshalNode
+// 一样只认识当前的schema_version，不认识就报错而不是硬解。
+func UnmarshalYAML(data []byte) (Node, error) {
+	lines := splitYAMLLines(string(data))
+	value, rest := parseYAMLBlock(lines, 0)
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("astyaml: trailing unparsed content starting at %q", rest[0].text)
+	}
+
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("astyaml: could not re-encode parsed YAML as JSON: %w", err)
+	}
+	return UnmarshalNode(jsonBytes)
+}
+
+// --- 编码: Go值 -> YAML文本 ---
+
+func writeYAMLValue(b *strings.Builder, v interface{}, indent int) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		writeYAMLMapping(b, val, indent)
+	case []interface{}:
+		writeYAMLSequence(b, val, indent)
+	default:
+		b.WriteString(yamlScalar(val))
+		b.WriteString("\n")
+	}
+}
+
+func writeYAMLMapping(b *strings.Builder, m map[string]interface{}, indent int) {
+	if len(m) == 0 {
+		b.WriteString("{}\n")
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pad := strings.Repeat("  ", indent)
+	for _, k := range keys {
+		v := m[k]
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if len(val) == 0 {
+				b.WriteString(pad + k + ": {}\n")
+				continue
+			}
+			b.WriteString(pad + k + ":\n")
+			writeYAMLMapping(b, val, indent+1)
+		case []interface{}:
+			if len(val) == 0 {
+				b.WriteString(pad + k + ": []\n")
+				continue
+			}
+			b.WriteString(pad + k + ":\n")
+			writeYAMLSequence(b, val, indent)
+		default:
+			b.WriteString(pad + k + ": " + yamlScalar(val) + "\n")
+		}
+	}
+}
+
+func writeYAMLSequence(b *strings.Builder, list []interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for _, item := range list {
+		switch val := item.(type) {
+		case map[string]interface{}:
+			if len(val) == 0 {
+				b.WriteString(pad + "- {}\n")
+				continue
+			}
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			b.WriteString(pad + "- " + keys[0] + ": " + yamlInlineOrBlock(val[keys[0]]))
+			rest := map[string]interface{}{}
+			for _, k := range keys[1:] {
+				rest[k] = val[k]
+			}
+			// 剩余字段缩进到和第一个key对齐，视觉上仍然属于同一个list item
+			writeYAMLMapping(b, rest, indent+1)
+		case []interface{}:
+			b.WriteString(pad + "-\n")
+			writeYAMLSequence(b, val, indent+1)
+		default:
+			b.WriteString(pad + "- " + yamlScalar(val) + "\n")
+		}
+	}
+}
+
+// yamlInlineOrBlock处理"- key: <nested>"里第一个key的值是mapping/
+// sequence的情况——为了不引入flow风格，这里简单地把值本身也换行展开
+func yamlInlineOrBlock(v interface{}) string {
+	switch val := v.(type) {
+	case map[string]interface{}, []interface{}:
+		var b strings.Builder
+		b.WriteString("\n")
+		writeYAMLValue(&b, val, 0)
+		return b.String()
+	default:
+		return yamlScalar(val) + "\n"
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(val)
+	case string:
+		return yamlQuoteString(val)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case int:
+		return strconv.Itoa(val)
+	default:
+		return yamlQuoteString(fmt.Sprintf("%v", val))
+	}
+}
+
+func yamlQuoteString(s string) string {
+	if s == "" || s == "null" || s == "true" || s == "false" ||
+		strings.ContainsAny(s, ":#{}[]&*!|>'\"%@`\n") || looksLikeYAMLNumber(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func looksLikeYAMLNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// --- 解码: YAML文本 -> Go值 ---
+
+type yamlLine struct {
+	indent int
+	text   string // 去掉缩进和行尾注释之后的内容
+}
+
+func splitYAMLLines(data string) []yamlLine {
+	var lines []yamlLine
+	for _, raw := range strings.Split(data, "\n") {
+		trimmed := strings.TrimRight(raw, " \r")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		indent := 0
+		for indent < len(trimmed) && trimmed[indent] == ' ' {
+			indent++
+		}
+		lines = append(lines, yamlLine{indent: indent, text: trimmed[indent:]})
+	}
+	return lines
+}
+
+// parseYAMLBlock解析从lines[0]开始、缩进恰好等于lines[0].indent的
+// 连续块，返回解析结果以及紧跟在这个块之后、缩进更浅的剩余行。
+func parseYAMLBlock(lines []yamlLine, minIndent int) (interface{}, []yamlLine) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	if strings.HasPrefix(lines[0].text, "- ") || lines[0].text == "-" {
+		return parseYAMLSequence(lines)
+	}
+	return parseYAMLMapping(lines)
+}
+
+func parseYAMLSequence(lines []yamlLine) (interface{}, []yamlLine) {
+	indent := lines[0].indent
+	var result []interface{}
+	for len(lines) > 0 && lines[0].indent == indent && (strings.HasPrefix(lines[0].text, "- ") || lines[0].text == "-") {
+		item := strings.TrimPrefix(lines[0].text, "-")
+		item = strings.TrimPrefix(item, " ")
+		rest := lines[1:]
+
+		if item == "" {
+			// "-" 独占一行，值是缩进更深的block
+			var nested interface{}
+			nested, rest = parseYAMLBlock(rest, indent+1)
+			result = append(result, nested)
+			lines = rest
+			continue
+		}
+		if strings.Contains(item, ": ") || strings.HasSuffix(item, ":") {
+			// "- key: value" 开头一个inline key，后续同缩进的
+			// "key: value"行属于同一个mapping
+			m := map[string]interface{}{}
+			k, v, hasValue := splitYAMLKeyValue(item)
+			if hasValue {
+				m[k] = v
+			} else {
+				var nested interface{}
+				nested, rest = parseYAMLBlock(rest, indent+1)
+				m[k] = nested
+			}
+			for len(rest) > 0 && rest[0].indent == indent+1 {
+				k2, v2, hasValue2 := splitYAMLKeyValue(rest[0].text)
+				if hasValue2 {
+					m[k2] = v2
+					rest = rest[1:]
+				} else {
+					var nested interface{}
+					nested, rest = parseYAMLBlock(rest[1:], indent+2)
+					m[k2] = nested
+				}
+			}
+			result = append(result, m)
+			lines = rest
+			continue
+		}
+		result = append(result, parseYAMLScalar(item))
+		lines = rest
+	}
+	return result, lines
+}
+
+func parseYAMLMapping(lines []yamlLine) (interface{}, []yamlLine) {
+	indent := lines[0].indent
+	m := map[string]interface{}{}
+	for len(lines) > 0 && lines[0].indent == indent && !strings.HasPrefix(lines[0].text, "- ") && lines[0].text != "-" {
+		k, v, hasValue := splitYAMLKeyValue(lines[0].text)
+		rest := lines[1:]
+		if hasValue {
+			m[k] = v
+			lines = rest
+			continue
+		}
+		var nested interface{}
+		if len(rest) > 0 && rest[0].indent > indent {
+			nested, rest = parseYAMLBlock(rest, rest[0].indent)
+		} else {
+			nested = map[string]interface{}{}
+		}
+		m[k] = nested
+		lines = rest
+	}
+	return m, lines
+}
+
+// splitYAMLKeyValue把"key: value"或"key:"拆成key和value；value为空
+// (hasValue=false)时调用方需要接着解析后面缩进更深的block
+func splitYAMLKeyValue(text string) (key string, value interface{}, hasValue bool) {
+	idx := strings.Index(text, ":")
+	if idx < 0 {
+		return text, nil, false
+	}
+	key = text[:idx]
+	rest := strings.TrimSpace(text[idx+1:])
+	if rest == "" {
+		return key, nil, false
+	}
+	if rest == "{}" {
+		return key, map[string]interface{}{}, true
+	}
+	if rest == "[]" {
+		return key, []interface{}{}, true
+	}
+	return key, parseYAMLScalar(rest), true
+}
+
+func parseYAMLScalar(s string) interface{} {
+	switch s {
+	case "null", "~":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
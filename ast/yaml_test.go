@@ -0,0 +1,72 @@
+package ast
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMarshalYAMLUnmarshalYAMLRoundTrip(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	original := NewAssignNode(NewVariableNode(NewIdentifier("x", pos), pos), NewZvalNode(float64(42), pos), pos)
+
+	data, err := MarshalYAML(original)
+	if err != nil {
+		t.Fatalf("MarshalYAML returned an error: %v", err)
+	}
+
+	got, err := UnmarshalYAML(data)
+	if err != nil {
+		t.Fatalf("UnmarshalYAML returned an error: %v\nyaml:\n%s", err, data)
+	}
+
+	assign, ok := got.(*AssignNode)
+	if !ok {
+		t.Fatalf("expected *AssignNode, got %T", got)
+	}
+	if assign.Right.(*ZvalNode).Value != float64(42) {
+		t.Errorf("expected right-hand value 42, got %#v", assign.Right.(*ZvalNode).Value)
+	}
+}
+
+// TestUnmarshalYAMLLoadsGoldenFixture演示testdata/golden-file用法：
+// 贡献者给新node类型加测试时，只需要在testdata/下放一个形如
+// "$x = 42;"对应AST的.yaml文件，不需要写Go代码手搭节点树。
+func TestUnmarshalYAMLLoadsGoldenFixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/simple_assign.yaml")
+	if err != nil {
+		t.Fatalf("could not read golden fixture: %v", err)
+	}
+
+	got, err := UnmarshalYAML(data)
+	if err != nil {
+		t.Fatalf("UnmarshalYAML returned an error: %v", err)
+	}
+
+	assign, ok := got.(*AssignNode)
+	if !ok {
+		t.Fatalf("expected *AssignNode, got %T", got)
+	}
+	variable, ok := assign.Left.(*VariableNode)
+	if !ok {
+		t.Fatalf("expected assign.Left to be *VariableNode, got %T", assign.Left)
+	}
+	if ident, ok := variable.Name.(*IdentifierNode); !ok || ident.Name != "x" {
+		t.Errorf("expected variable name %q, got %#v", "x", variable.Name)
+	}
+	if zval, ok := assign.Right.(*ZvalNode); !ok || zval.Value != float64(42) {
+		t.Errorf("expected right-hand value 42, got %#v", assign.Right)
+	}
+}
+
+func TestMarshalYAMLProducesBlockStyleNotFlowStyle(t *testing.T) {
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+	data, err := MarshalYAML(NewZvalNode(int64(1), pos))
+	if err != nil {
+		t.Fatalf("MarshalYAML returned an error: %v", err)
+	}
+	for _, forbidden := range []string{"{", "}", "[", "]"} {
+		if contains(string(data), forbidden) {
+			t.Errorf("expected block-style YAML with no flow collections, found %q in:\n%s", forbidden, data)
+		}
+	}
+}
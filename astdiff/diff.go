@@ -0,0 +1,536 @@
+// Package astdiff 计算两棵ast.Node树之间的结构差异，并能把差异
+// 重新应用到一棵树上。匹配算法参照GumTree：先用子树哈希做"完全
+// 相同子树"的快速配对(bottom-up)，再按子树从大到小贪心地把匹配
+// 钉死(top-down)，最后对父节点已匹配、自身还没匹配上的节点按
+// kind+label相似度做一次恢复匹配。Position差异被忽略——两棵树
+// 即便只是reparse同一段代码、行号完全不同，也应该被判定为毫无
+// 变化。
+package astdiff
+
+import (
+	"fmt"
+
+	"github.com/wudi/php-parser/ast"
+)
+
+// EditKind 描述一条编辑操作的种类
+type EditKind int
+
+const (
+	EditInsert EditKind = iota // b中新增、a里没有对应节点
+	EditDelete                 // a中存在、b里已经没有对应节点
+	EditUpdate                 // 匹配上了，但判别性字段(label)变了
+	EditMove                   // 匹配上了，但在b树里换了父节点
+)
+
+func (k EditKind) String() string {
+	switch k {
+	case EditInsert:
+		return "insert"
+	case EditDelete:
+		return "delete"
+	case EditUpdate:
+		return "update"
+	case EditMove:
+		return "move"
+	default:
+		return "unknown"
+	}
+}
+
+// Edit 是编辑脚本里的一条操作。不同Kind用到的字段不同：Insert用
+// Node/Parent/Index，Delete只用Node，Update用Node/OldLabel/NewLabel，
+// Move用Node/Parent
+type Edit struct {
+	Kind     EditKind
+	Node     ast.Node
+	Parent   ast.Node
+	Index    int
+	OldLabel string
+	NewLabel string
+}
+
+func (e Edit) String() string {
+	return fmt.Sprintf("%s %s", e.Kind, e.Node.String())
+}
+
+// Mapping 是a树节点到b树节点的一一对应关系
+type Mapping map[ast.Node]ast.Node
+
+// nodeLabel 提取节点里用于判断"内容是否相同"的判别性字段，对应
+// GumTree里每个节点的label：运算符、复合赋值的操作符、字面量的值、
+// 常量/标识符的名字。拿不到判别字段的节点返回空字符串，此时节点
+// 是否"相同"完全由kind和子树结构决定
+func nodeLabel(n ast.Node) string {
+	switch node := n.(type) {
+	case *ast.BinaryNode:
+		return node.Operator
+	case *ast.UnaryNode:
+		return node.Operator
+	case *ast.AssignNode:
+		return node.Op
+	case *ast.ZvalNode:
+		return fmt.Sprintf("%v", node.Value)
+	case *ast.ConstantNode:
+		return node.Name
+	case *ast.IdentifierNode:
+		return node.Name
+	default:
+		return ""
+	}
+}
+
+const (
+	fnvOffset uint64 = 14695981039346656037
+	fnvPrime  uint64 = 1099511628211
+)
+
+func fnvMix(h uint64, b uint64) uint64 {
+	h ^= b
+	h *= fnvPrime
+	return h
+}
+
+// subtreeHash 自底向上给一棵树里每个节点计算一个近似同构哈希：
+// kind+label+子节点哈希的组合，写入out以便match()按哈希值检索。
+// 哈希相同强烈暗示两棵子树同构，但不是证明(理论上可能碰撞)——这里
+// 接受这个近似，换取避免逐节点深度比较的O(1)候选检索。
+func subtreeHash(n ast.Node, out map[ast.Node]uint64) uint64 {
+	if n == nil {
+		return 0
+	}
+
+	h := fnvOffset
+	h = fnvMix(h, uint64(n.GetKind()))
+	for _, c := range nodeLabel(n) {
+		h = fnvMix(h, uint64(c))
+	}
+	for _, child := range n.GetChildren() {
+		h = fnvMix(h, subtreeHash(child, out))
+	}
+
+	out[n] = h
+	return h
+}
+
+func postOrder(n ast.Node, fn func(ast.Node)) {
+	if n == nil {
+		return
+	}
+	for _, c := range n.GetChildren() {
+		postOrder(c, fn)
+	}
+	fn(n)
+}
+
+func countNodes(n ast.Node) int {
+	count := 0
+	postOrder(n, func(ast.Node) { count++ })
+	return count
+}
+
+// match 实现三阶段匹配：bottom-up哈希配对同构子树、按子树大小从大
+// 到小贪心钉死匹配(postOrder的逆序天然保证父节点先于子节点被考虑，
+// 父节点子树总比子节点大)、top-down地把a/b两棵树的根节点本身配成一
+// 对(只要kind相同，哪怕label或子节点个数不一样)并沿着对齐后的子节点
+// 序列递归下降、以及对剩余节点的kind+label相似度恢复。
+//
+// bottom-up阶段只能发现"完全同构"的子树——subtreeHash把节点自己的
+// label也folding进了哈希，所以一个运算符被改过的二元表达式永远不会
+// 和它自己修改前的版本哈希相同，bottom-up阶段因此永远配不上这种
+// "同一个节点、内容变了"的情况，更不会去尝试配对两棵从未被哪个
+// bottom-up哈希配上的子树的根节点——包括a、b自身的树根。top-down阶段
+// 补上这一课：直接把a、b当成第一对候选(它们就是Diff()要比较的对象)，
+// kind相同就认定是同一个节点(可能是update/move)，再用alignChildren
+// 对子节点序列做一次LCS对齐，递归下降，而不是整段放弃为delete+insert。
+func match(a, b ast.Node) Mapping {
+	aHash := map[ast.Node]uint64{}
+	bHash := map[ast.Node]uint64{}
+	subtreeHash(a, aHash)
+	subtreeHash(b, bHash)
+
+	bByHash := map[uint64][]ast.Node{}
+	postOrder(b, func(n ast.Node) { bByHash[bHash[n]] = append(bByHash[bHash[n]], n) })
+
+	mapping := Mapping{}
+	matchedB := map[ast.Node]bool{}
+
+	var aNodes []ast.Node
+	postOrder(a, func(n ast.Node) { aNodes = append(aNodes, n) })
+
+	for i := len(aNodes) - 1; i >= 0; i-- {
+		an := aNodes[i]
+		if _, already := mapping[an]; already {
+			continue
+		}
+		for _, bn := range bByHash[aHash[an]] {
+			if matchedB[bn] {
+				continue
+			}
+			mapping[an] = bn
+			matchedB[bn] = true
+			mapSubtreeChildren(an, bn, mapping, matchedB)
+			break
+		}
+	}
+
+	matchTopDown(a, b, aHash, bHash, mapping, matchedB)
+	recoverMatches(a, mapping, matchedB)
+
+	return mapping
+}
+
+// matchTopDown把an、bn配成一对(只要两边都还没匹配过、且kind相同)，
+// 然后递归下降：子树哈希完全相等就交给mapSubtreeChildren一次性整棵
+// 子树配对；否则用alignChildren对两边的子节点序列做LCS对齐，对齐上
+// 的每一对递归调用自己。和bottom-up阶段要求"完全同构"不同，这里只
+// 要求kind相同，所以能发现"同一个节点，判别性字段或子节点个数变了"
+// 这种bottom-up哈希必然错过的配对——包括调用方直接传入的a、b自身。
+func matchTopDown(an, bn ast.Node, aHash, bHash map[ast.Node]uint64, mapping Mapping, matchedB map[ast.Node]bool) {
+	if an == nil || bn == nil {
+		return
+	}
+	if _, ok := mapping[an]; ok {
+		return
+	}
+	if matchedB[bn] {
+		return
+	}
+	if an.GetKind() != bn.GetKind() {
+		return
+	}
+
+	mapping[an] = bn
+	matchedB[bn] = true
+
+	if aHash[an] == bHash[bn] {
+		mapSubtreeChildren(an, bn, mapping, matchedB)
+		return
+	}
+
+	for _, pair := range alignChildren(an.GetChildren(), bn.GetChildren(), aHash, bHash) {
+		matchTopDown(pair[0], pair[1], aHash, bHash, mapping, matchedB)
+	}
+}
+
+// alignChildren用最长公共子序列(以子树哈希相等作为"相同"的判据)对齐
+// aChildren/bChildren两个子节点序列：公共子序列里的每一对直接认定是
+// 同一个节点；公共子序列之间的"空隙"如果两边长度相等，按位置再配
+// 一轮，用来捕捉"同一个位置上的节点内容变了"这种update，而不是把
+// 整段拆成delete+insert；长度不相等的空隙保留为未匹配，交给Diff()
+// 产出insert/delete——这正是列表里"插入/删除一个元素"这种最常见情形
+// 需要的对齐方式，比把两边子节点按下标强行一一对应、或者只在父节点
+// 匹配上之后各自独立地找"最相似兄弟"(recoverMatches的做法)要准确。
+func alignChildren(aChildren, bChildren []ast.Node, aHash, bHash map[ast.Node]uint64) [][2]ast.Node {
+	n, m := len(aChildren), len(bChildren)
+	equal := func(i, j int) bool { return aHash[aChildren[i]] == bHash[bChildren[j]] }
+
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case equal(i, j):
+				dp[i][j] = dp[i+1][j+1] + 1
+			case dp[i+1][j] >= dp[i][j+1]:
+				dp[i][j] = dp[i+1][j]
+			default:
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+
+	var pairs [][2]ast.Node
+	gapAStart, gapBStart := 0, 0
+	flushGap := func(aEnd, bEnd int) {
+		if aLen, bLen := aEnd-gapAStart, bEnd-gapBStart; aLen == bLen {
+			for k := 0; k < aLen; k++ {
+				pairs = append(pairs, [2]ast.Node{aChildren[gapAStart+k], bChildren[gapBStart+k]})
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case equal(i, j):
+			flushGap(i, j)
+			pairs = append(pairs, [2]ast.Node{aChildren[i], bChildren[j]})
+			i++
+			j++
+			gapAStart, gapBStart = i, j
+		case dp[i+1][j] >= dp[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	flushGap(n, m)
+
+	return pairs
+}
+
+// mapSubtreeChildren 在an/bn已经被判定为同构子树根之后，顺着两边
+// 的子节点逐一配对，不需要再重新走一遍哈希检索
+func mapSubtreeChildren(an, bn ast.Node, mapping Mapping, matchedB map[ast.Node]bool) {
+	aChildren := an.GetChildren()
+	bChildren := bn.GetChildren()
+	for i := 0; i < len(aChildren) && i < len(bChildren); i++ {
+		if _, ok := mapping[aChildren[i]]; ok {
+			continue
+		}
+		mapping[aChildren[i]] = bChildren[i]
+		matchedB[bChildren[i]] = true
+		mapSubtreeChildren(aChildren[i], bChildren[i], mapping, matchedB)
+	}
+}
+
+// recoverMatches对父节点已经匹配、自身还没匹配上的a节点，去它在b中
+// 对应父节点的未匹配子节点里找kind相同且label最相似的一个
+func recoverMatches(a ast.Node, mapping Mapping, matchedB map[ast.Node]bool) {
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		for _, c := range n.GetChildren() {
+			if c == nil {
+				continue
+			}
+			if _, ok := mapping[c]; !ok {
+				if bn, ok2 := mapping[n]; ok2 {
+					if best := bestUnmatchedSibling(c, bn, matchedB); best != nil {
+						mapping[c] = best
+						matchedB[best] = true
+					}
+				}
+			}
+			walk(c)
+		}
+	}
+	walk(a)
+}
+
+func bestUnmatchedSibling(ac ast.Node, bn ast.Node, matchedB map[ast.Node]bool) ast.Node {
+	var best ast.Node
+	bestScore := -1.0
+	for _, bc := range bn.GetChildren() {
+		if bc == nil || matchedB[bc] || bc.GetKind() != ac.GetKind() {
+			continue
+		}
+		if score := labelSimilarity(ac, bc); score > bestScore {
+			bestScore = score
+			best = bc
+		}
+	}
+	return best
+}
+
+func labelSimilarity(a, b ast.Node) float64 {
+	if nodeLabel(a) == nodeLabel(b) {
+		return 1
+	}
+	return 0
+}
+
+func parentIndex(root ast.Node) map[ast.Node]ast.Node {
+	parents := map[ast.Node]ast.Node{}
+	var walk func(n, parent ast.Node)
+	walk = func(n, parent ast.Node) {
+		parents[n] = parent
+		for _, c := range n.GetChildren() {
+			walk(c, n)
+		}
+	}
+	walk(root, nil)
+	return parents
+}
+
+// indexOfChild返回n在parent.GetChildren()里的下标，找不到(parent为
+// nil，或者n不在其中，比如n就是树根本身)时返回0。
+func indexOfChild(parent, n ast.Node) int {
+	if parent == nil {
+		return 0
+	}
+	for i, c := range parent.GetChildren() {
+		if c == n {
+			return i
+		}
+	}
+	return 0
+}
+
+// Diff计算从a到b的最小编辑脚本。先跑match()得到节点映射，再依次
+// 产出：a中未匹配的节点(delete)、b中未匹配的节点(insert)、匹配上
+// 但label变了的节点(update)、匹配上但父节点对不上的节点(move)。
+//
+// 注意：Diff会产出EditMove(比如一个表达式被从if的then分支挪到了
+// else分支，节点本身没变、父节点变了)，但Apply目前完全不支持应用
+// Move——遇到edits里的第一条Move就会直接返回错误，连它之前已经成功
+// 应用的Update/Insert/Delete也会停在那一步，不会继续处理脚本里排在
+// Move后面的其余编辑。也就是说，如果调用方打算把Diff的结果喂给
+// Apply，必须先检查编辑脚本里有没有EditMove(或者准备好处理Apply
+// 返回的"move edits are not yet supported"错误)，见Apply的文档和
+// TestApplyRejectsMoveEdits。
+func Diff(a, b ast.Node) []Edit {
+	mapping := match(a, b)
+
+	var edits []Edit
+
+	postOrder(a, func(n ast.Node) {
+		if _, ok := mapping[n]; !ok {
+			edits = append(edits, Edit{Kind: EditDelete, Node: n})
+		}
+	})
+
+	matchedBSet := make(map[ast.Node]bool, len(mapping))
+	reverseMapping := make(map[ast.Node]ast.Node, len(mapping))
+	for an, bn := range mapping {
+		matchedBSet[bn] = true
+		reverseMapping[bn] = an
+	}
+
+	parentOfA := parentIndex(a)
+	parentOfB := parentIndex(b)
+
+	// Parent必须是a树里的节点，因为Apply最终是在a(即root)上做修改；
+	// n在b树里的父节点要通过reverseMapping换算回它在a树里对应的那个
+	// 节点，直接拿b树的父节点当Parent只会悄悄改掉b自己、对a毫无作用。
+	//
+	// n自己没匹配上时，不再往下递归：e.Node就是n这个完整的b子树(连同
+	// 它现成的GetChildren())，插入这一条edit就已经把整棵子树一起带
+	// 进去了。继续递归下去会给子树里每个节点各自再产出一条Insert，
+	// 而那些子节点的b父节点同样没匹配、reverseMapping查不到，Parent
+	// 退化成nil，Apply处理到这种edit时会对nil Parent取Kind直接panic。
+	// 只有n已经匹配上、子树的其余部分是原样搬过来的情况下，才需要
+	// 继续往下找"这棵大体没变的子树里新冒出来的节点"。
+	var walkInsert func(n ast.Node)
+	walkInsert = func(n ast.Node) {
+		if n == nil {
+			return
+		}
+		if !matchedBSet[n] {
+			bParent := parentOfB[n]
+			aParent := reverseMapping[bParent]
+			index := indexOfChild(bParent, n)
+			edits = append(edits, Edit{Kind: EditInsert, Node: n, Parent: aParent, Index: index})
+			return
+		}
+		for _, c := range n.GetChildren() {
+			walkInsert(c)
+		}
+	}
+	walkInsert(b)
+
+	for an, bn := range mapping {
+		if la, lb := nodeLabel(an), nodeLabel(bn); la != lb {
+			edits = append(edits, Edit{Kind: EditUpdate, Node: an, OldLabel: la, NewLabel: lb})
+		}
+	}
+
+	for an, bn := range mapping {
+		aParent, bParent := parentOfA[an], parentOfB[bn]
+		if aParent == nil || bParent == nil {
+			continue
+		}
+		if expectedBParent, ok := mapping[aParent]; !ok || expectedBParent != bParent {
+			edits = append(edits, Edit{Kind: EditMove, Node: an, Parent: bParent})
+		}
+	}
+
+	return edits
+}
+
+// MatchScore返回a、b两棵子树的相似度(Dice系数)：match()找到的匹配
+// 节点对数乘2，除以两棵树各自的节点总数。1表示完全同构，0表示毫无
+// 共同结构。调用方可以用它给recoverMatches里默认接受的恢复匹配再
+// 加一道"这两棵树到底够不够像"的门槛。
+func MatchScore(a, b ast.Node) float64 {
+	mapping := match(a, b)
+	total := countNodes(a) + countNodes(b)
+	if total == 0 {
+		return 1
+	}
+	return float64(2*len(mapping)) / float64(total)
+}
+
+// Apply按顺序把edits应用到root上。受限于当前AST没有通用的"替换
+// 任意位置子节点"能力(参见ast.Rewriter里setChild的同类限制)：
+// Update能通用处理，因为它只是改写节点自带的标签字段；Insert/Delete
+// 只在父节点是*ast.ListNode时才能做到(按索引增删Elements)；Move
+// 目前还不支持，会让Apply提前返回错误。遇到做不到的edit时返回到
+// 那一步为止已经应用的根节点和一个指出具体原因的error，而不是悄悄
+// 丢弃这条编辑。
+func Apply(root ast.Node, edits []Edit) (ast.Node, error) {
+	for _, e := range edits {
+		var err error
+		switch e.Kind {
+		case EditUpdate:
+			err = applyUpdate(e)
+		case EditInsert:
+			err = applyInsert(e)
+		case EditDelete:
+			err = applyDelete(root, e)
+		case EditMove:
+			err = fmt.Errorf("astdiff: move edits are not yet supported by Apply (node %s)", e.Node)
+		}
+		if err != nil {
+			return root, err
+		}
+	}
+	return root, nil
+}
+
+func applyUpdate(e Edit) error {
+	switch n := e.Node.(type) {
+	case *ast.BinaryNode:
+		n.Operator = e.NewLabel
+	case *ast.UnaryNode:
+		n.Operator = e.NewLabel
+	case *ast.AssignNode:
+		n.Op = e.NewLabel
+	default:
+		return fmt.Errorf("astdiff: update not supported for node kind %s", e.Node.GetKind())
+	}
+	return nil
+}
+
+func applyInsert(e Edit) error {
+	if e.Parent == nil {
+		return fmt.Errorf("astdiff: insert has no parent in the target tree for node %s", e.Node)
+	}
+	list, ok := e.Parent.(*ast.ListNode)
+	if !ok {
+		return fmt.Errorf("astdiff: insert only supported under list nodes, got parent kind %s", e.Parent.GetKind())
+	}
+
+	idx := e.Index
+	if idx < 0 || idx > len(list.Elements) {
+		idx = len(list.Elements)
+	}
+	list.Elements = append(list.Elements, nil)
+	copy(list.Elements[idx+1:], list.Elements[idx:])
+	list.Elements[idx] = e.Node
+	list.Children = list.Elements
+	return nil
+}
+
+func applyDelete(root ast.Node, e Edit) error {
+	parent, ok := parentIndex(root)[e.Node]
+	if !ok || parent == nil {
+		return fmt.Errorf("astdiff: could not locate parent of deleted node %s", e.Node)
+	}
+
+	list, ok := parent.(*ast.ListNode)
+	if !ok {
+		return fmt.Errorf("astdiff: delete only supported under list nodes, got parent kind %s", parent.GetKind())
+	}
+
+	for i, el := range list.Elements {
+		if el == e.Node {
+			list.Elements = append(list.Elements[:i], list.Elements[i+1:]...)
+			list.Children = list.Elements
+			return nil
+		}
+	}
+	return fmt.Errorf("astdiff: deleted node %s not found among parent's elements", e.Node)
+}
@@ -0,0 +1,178 @@
+package astdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wudi/php-parser/ast"
+)
+
+func TestDiffIdenticalTreesProduceNoEdits(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	buildTree := func() ast.Node {
+		left := ast.NewIntegerLiteral(1, pos)
+		right := ast.NewIntegerLiteral(2, pos)
+		return ast.NewBinaryNode(ast.AST_BINARY_OP, "+", left, right, pos)
+	}
+
+	edits := Diff(buildTree(), buildTree())
+	if len(edits) != 0 {
+		t.Errorf("expected no edits between identical trees, got %v", edits)
+	}
+
+	if score := MatchScore(buildTree(), buildTree()); score != 1 {
+		t.Errorf("expected MatchScore 1 for identical trees, got %v", score)
+	}
+}
+
+func TestDiffDetectsOperatorUpdate(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	a := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+	b := ast.NewBinaryNode(ast.AST_BINARY_OP, "-", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+
+	edits := Diff(a, b)
+
+	found := false
+	for _, e := range edits {
+		if e.Kind == EditUpdate && e.OldLabel == "+" && e.NewLabel == "-" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected an update edit changing '+' to '-', got %v", edits)
+	}
+}
+
+func TestDiffDetectsInsertAndDeleteInList(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	a := ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(1, pos)})
+	b := ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos)})
+
+	edits := Diff(a, b)
+
+	var inserts, deletes int
+	for _, e := range edits {
+		switch e.Kind {
+		case EditInsert:
+			inserts++
+		case EditDelete:
+			deletes++
+		}
+	}
+	if inserts != 1 || deletes != 0 {
+		t.Errorf("expected exactly 1 insert and 0 deletes, got inserts=%d deletes=%d (%v)", inserts, deletes, edits)
+	}
+}
+
+func TestApplyUpdateRewritesOperator(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	a := ast.NewBinaryNode(ast.AST_BINARY_OP, "+", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+	b := ast.NewBinaryNode(ast.AST_BINARY_OP, "-", ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos), pos)
+
+	edits := Diff(a, b)
+	root, err := Apply(a, edits)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	binary, ok := root.(*ast.BinaryNode)
+	if !ok || binary.Operator != "-" {
+		t.Errorf("expected operator to be rewritten to '-', got %#v", root)
+	}
+}
+
+func TestApplyInsertAddsListElement(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	a := ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(1, pos)})
+	b := ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(1, pos), ast.NewIntegerLiteral(2, pos)})
+
+	edits := Diff(a, b)
+	root, err := Apply(a, edits)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	list := root.(*ast.ListNode)
+	if len(list.Elements) != 2 {
+		t.Fatalf("expected 2 elements after insert, got %d", len(list.Elements))
+	}
+	if v, ok := list.Elements[1].(*ast.ZvalNode); !ok || v.Value != int64(2) {
+		t.Errorf("expected second element to be the inserted literal 2, got %#v", list.Elements[1])
+	}
+}
+
+// TestApplyRejectsMoveEdits记录了一个已知的、有意的缺口：Diff完全
+// 有能力产出EditMove(一个节点内容没变、只是换了父节点)，但Apply目前
+// 完全不支持应用它，遇到第一条Move就直接返回错误，哪怕edits里排在
+// 它前面的Update/Insert/Delete已经成功应用到root上了。这个测试把
+// 这个缺口钉在测试套件里：先构造一个真的会让Diff产出Move的场景(字面
+// 量1从inner1搬到了inner2下面，节点本身没变)，确认Diff确实产出了
+// EditMove，再确认Apply会报出Diff文档里说明的那个错误，而不是悄悄
+// 丢弃这条编辑或者panic
+func TestApplyRejectsMoveEdits(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	a := ast.NewStatementList([]ast.Node{
+		ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(1, pos)}),
+		ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(2, pos)}),
+	})
+	b := ast.NewStatementList([]ast.Node{
+		ast.NewStatementList(nil),
+		ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(2, pos), ast.NewIntegerLiteral(1, pos)}),
+	})
+
+	edits := Diff(a, b)
+
+	var moves int
+	for _, e := range edits {
+		if e.Kind == EditMove {
+			moves++
+		}
+	}
+	if moves == 0 {
+		t.Fatalf("expected Diff to produce at least one move edit, got %v", edits)
+	}
+
+	_, err := Apply(a, edits)
+	if err == nil {
+		t.Fatalf("expected Apply to reject an edit script containing a move, got no error")
+	}
+	if want := "move edits are not yet supported by Apply"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to mention %q, got %q", want, err.Error())
+	}
+}
+
+func TestApplyInsertAddsWholeNestedSubtree(t *testing.T) {
+	pos := ast.Position{Line: 1, Column: 1}
+	a := ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(1, pos)})
+	newInner := ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(9, pos), ast.NewIntegerLiteral(8, pos)})
+	b := ast.NewStatementList([]ast.Node{ast.NewIntegerLiteral(1, pos), newInner})
+
+	edits := Diff(a, b)
+
+	var inserts, deletes int
+	for _, e := range edits {
+		switch e.Kind {
+		case EditInsert:
+			inserts++
+		case EditDelete:
+			deletes++
+		}
+	}
+	if inserts != 1 || deletes != 0 {
+		t.Fatalf("expected the whole new nested list to land as a single insert, got inserts=%d deletes=%d (%v)", inserts, deletes, edits)
+	}
+
+	root, err := Apply(a, edits)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	list := root.(*ast.ListNode)
+	if len(list.Elements) != 2 {
+		t.Fatalf("expected 2 elements after insert, got %d", len(list.Elements))
+	}
+	inner, ok := list.Elements[1].(*ast.ListNode)
+	if !ok || len(inner.Elements) != 2 {
+		t.Errorf("expected second element to be the inserted 2-element nested list, got %#v", list.Elements[1])
+	}
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/wudi/php-parser/lexer"
+)
+
+// Severity 表示一条诊断信息的严重程度
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
+)
+
+// String 返回严重程度的可读名称
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalJSON 将严重程度序列化为其名称，便于编辑器/LSP客户端消费
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ParseError 是一条带有完整源码跨度的结构化诊断，取代早期只记录
+// 单个token位置的SyntaxError。StartPos/EndPos界定出错的源码范围，
+// Expected列出恢复时预期能看到的token，供工具渲染"期望X，实际Y"提示。
+// Related可以挂载与本诊断相关联的其它诊断(例如"此处声明"提示)。
+type ParseError struct {
+	StartPos lexer.Position `json:"start"`
+	EndPos   lexer.Position `json:"end"`
+	Severity Severity       `json:"severity"`
+	Code     string         `json:"code,omitempty"`
+	Message  string         `json:"message"`
+	Hint     string         `json:"hint,omitempty"`
+	Expected []string       `json:"expected,omitempty"`
+	Related  []ParseError   `json:"related,omitempty"`
+}
+
+// Error 实现error接口，使ParseError可以直接追加到Parser.errors中
+func (e ParseError) Error() string {
+	if e.StartPos.Line == e.EndPos.Line && e.StartPos.Column == e.EndPos.Column {
+		return fmt.Sprintf("%s: %s (at %d:%d)", e.Severity, e.Message, e.StartPos.Line, e.StartPos.Column)
+	}
+	return fmt.Sprintf("%s: %s (at %d:%d-%d:%d)", e.Severity, e.Message,
+		e.StartPos.Line, e.StartPos.Column, e.EndPos.Line, e.EndPos.Column)
+}
+
+// Diagnostics 返回本次解析产生的全部结构化诊断，相比Errors()
+// 保留了源码跨度、严重程度和提示信息，适合渲染成多条诊断而不是
+// 在第一个错误处停止
+func (p *Parser) Diagnostics() []ParseError {
+	return p.diagnostics
+}
+
+// addDiagnostic 构造一条ParseError并同时记入p.errors(保持Errors()
+// 的向后兼容)和p.diagnostics(提供完整的结构化信息)
+func (p *Parser) addDiagnostic(severity Severity, code, message, hint string, expected []string) {
+	start := lexer.Position{
+		Line:   p.currentToken.Position.Line,
+		Column: p.currentToken.Position.Column,
+		Offset: p.currentToken.Position.Offset,
+	}
+	end := start
+	if width := len(p.currentToken.Value); width > 0 {
+		end.Column += width
+		end.Offset += width
+	}
+
+	diag := ParseError{
+		StartPos: start,
+		EndPos:   end,
+		Severity: severity,
+		Code:     code,
+		Message:  message,
+		Hint:     hint,
+		Expected: expected,
+	}
+
+	p.diagnostics = append(p.diagnostics, diag)
+	p.errors = append(p.errors, diag)
+}
+
+// FormatDiagnostic 渲染一条诊断为带插入符(^)标注的源码片段，
+// 例如编辑器/终端中常见的错误提示格式
+func FormatDiagnostic(src []byte, d ParseError) string {
+	lines := bytes.Split(src, []byte("\n"))
+	if d.StartPos.Line < 1 || d.StartPos.Line > len(lines) {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	line := string(lines[d.StartPos.Line-1])
+
+	width := d.EndPos.Column - d.StartPos.Column
+	if width < 1 {
+		width = 1
+	}
+	caretLine := strings.Repeat(" ", max(d.StartPos.Column-1, 0)) + strings.Repeat("^", width)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d:%d: %s: %s\n", d.StartPos.Line, d.StartPos.Column, d.Severity, d.Message)
+	b.WriteString(line)
+	b.WriteString("\n")
+	b.WriteString(caretLine)
+	if d.Hint != "" {
+		fmt.Fprintf(&b, "\nhint: %s", d.Hint)
+	}
+	return b.String()
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -7,31 +7,327 @@ import (
 
 	"github.com/wudi/php-parser/ast"
 	"github.com/wudi/php-parser/lexer"
-	"github.com/wudi/php-parser/errors"
 )
 
+// prefixParseFn 前缀解析函数，对应某个token类型的前缀产生式
+type prefixParseFn func() ast.Node
+
+// infixParseFn 中缀解析函数，接收已解析的左操作数
+type infixParseFn func(left ast.Node) ast.Node
+
 // Parser 递归下降解析器，基于PHP官方语法规则
 type Parser struct {
 	lexer        *lexer.Lexer
 	currentToken lexer.Token
 	peekToken    lexer.Token
 	errors       []error
+
+	// prefixParseFns/infixParseFns 将token类型映射到对应的解析函数，
+	// 取代原先的硬编码switch，允许第三方(或特定PHP版本的扩展)
+	// 通过RegisterPrefix/RegisterInfix注册新token而无需改动解析器核心
+	prefixParseFns map[lexer.TokenType]prefixParseFn
+	infixParseFns  map[lexer.TokenType]infixParseFn
+
+	// precedences 是每个解析器实例自己的优先级表，默认从precedenceMap
+	// 拷贝而来，用户可以覆盖或新增条目(例如为自定义运算符指定优先级)
+	precedences map[lexer.TokenType]Precedence
+
+	// errorLimit 限制单次解析中可以记录的错误数量，避免在错误恢复
+	// 反复失败的病态输入上无限循环。0表示使用defaultErrorLimit
+	errorLimit int
+
+	// diagnostics 保存结构化的ParseError，携带完整源码跨度、
+	// 严重程度和提示信息；errors继续保留仅用于向后兼容Errors()
+	diagnostics []ParseError
+
+	// version 决定哪些按PHP版本门控的前缀/中缀产生式会被注册，
+	// 默认为LatestVersion，即New()的行为保持不变
+	version Version
+
+	// logger接收结构化的解析期诊断(token消费、错误、panic恢复)。
+	// 默认是ast.NopLogger{}，不会产生任何输出；想把解析器嵌入
+	// language server或CI linter、需要带kind/position的逐节点日志时
+	// 用SetLogger换成真正的实现(接到lgr/zap/slog等)
+	logger ast.Logger
+
+	// source 是构造这个Parser时用到的完整源码文本，只有经由
+	// NewFromSource/NewFromSourceWithVersion创建的Parser才会设置它。
+	// ParseIncremental需要它来推算一次编辑之后的新源码、并据此构造
+	// 重新解析受影响区间所需的子Parser。hasSource标记source是否真的
+	// 来自这两个构造函数——不能用source==""当作"没有source"的信号，
+	// 因为NewFromSource("")本身就是解析一个空文件的合法用法，空字符串
+	// 是它正常设置的值，不是"未设置"
+	source    string
+	hasSource bool
+
+	// incrementalUsed标记ParseIncremental是否已经在这个Parser上用
+	// 掉了它的一次编辑；见stream.go里ParseIncremental的文档
+	incrementalUsed bool
+}
+
+// defaultErrorLimit 是errorLimit未显式设置时使用的默认值
+const defaultErrorLimit = 200
+
+// defaultSyncTokens 是parseTopStatement发生panic后recover()用来
+// 跳转的默认同步点：语句/块结束符或下一个顶层声明的起始token
+var defaultSyncTokens = []lexer.TokenType{
+	lexer.TOKEN_SEMICOLON,
+	lexer.TOKEN_RBRACE,
+	lexer.T_FUNCTION,
+	lexer.T_CLASS,
+	lexer.T_NAMESPACE,
+	lexer.T_EOF,
+}
+
+// SetErrorLimit 设置本次解析允许记录的最大错误数
+func (p *Parser) SetErrorLimit(limit int) {
+	p.errorLimit = limit
+}
+
+// SetLogger 设置接收结构化解析期诊断的logger，传nil等价于换回
+// ast.NopLogger{}(关闭日志而不是panic)
+func (p *Parser) SetLogger(log ast.Logger) {
+	if log == nil {
+		log = ast.NopLogger{}
+	}
+	p.logger = log
+}
+
+// log 返回p当前使用的logger，未显式调用过SetLogger时是ast.NopLogger{}
+func (p *Parser) log() ast.Logger {
+	if p.logger == nil {
+		return ast.NopLogger{}
+	}
+	return p.logger
+}
+
+// errorLimitReached 判断是否已经达到错误数量上限
+func (p *Parser) errorLimitReached() bool {
+	limit := p.errorLimit
+	if limit == 0 {
+		limit = defaultErrorLimit
+	}
+	return len(p.errors) >= limit
 }
 
-// New 创建新的解析器实例
+// recover 跳过token直到遇到sync中列出的同步点(或T_EOF)，
+// 用于panic-mode错误恢复：一条产生式解析失败后，跳过残留的
+// 无法识别的token，使解析器能够从下一条语句/声明继续
+func (p *Parser) recover(sync ...lexer.TokenType) {
+	syncSet := make(map[lexer.TokenType]bool, len(sync)+1)
+	syncSet[lexer.T_EOF] = true
+	for _, tok := range sync {
+		syncSet[tok] = true
+	}
+
+	skipped := 0
+	for !syncSet[p.currentToken.Type] {
+		p.nextToken()
+		skipped++
+	}
+	if skipped > 0 {
+		p.log().Warnf("recovered after skipping %d token(s), resuming at %v", skipped, p.currentToken.Type)
+	}
+	// 同步点本身(如';'或'}')也一并消费掉，除非是T_EOF或下一条
+	// 顶层声明的起始token(不应该被吞掉)
+	switch p.currentToken.Type {
+	case lexer.TOKEN_SEMICOLON, lexer.TOKEN_RBRACE:
+		p.nextToken()
+	}
+}
+
+// New 创建新的解析器实例，使用LatestVersion，即接受所有受支持的
+// PHP语法(含8.4的管道运算符)。需要针对特定PHP版本解析时请改用
+// NewWithVersion
 func New(l *lexer.Lexer) *Parser {
+	return NewWithVersion(l, LatestVersion)
+}
+
+// NewWithVersion 创建一个按指定PHP版本门控语法特性的解析器实例。
+// 例如match/nullsafe运算符只在>=8.0时注册，enum只在>=8.1时接受，
+// 管道运算符`|>`只在>=8.4时注册；在更低版本的源码中遇到这些构造
+// 会产生带版本提示的ParseError而不是默默地解析成功
+func NewWithVersion(l *lexer.Lexer, v Version) *Parser {
 	p := &Parser{
-		lexer:  l,
-		errors: []error{},
+		lexer:   l,
+		errors:  []error{},
+		version: v,
 	}
-	
+
+	p.registerDefaults()
+
 	// 读取两个token，初始化currentToken和peekToken
 	p.nextToken()
 	p.nextToken()
-	
+
+	return p
+}
+
+// NewFromSource 和New等价(使用LatestVersion)，但额外记住source，
+// 使返回的Parser可以调用ParseIncremental。不需要增量重新解析的调用方
+// 继续用New/NewWithVersion即可，没有必要为了保留source文本多付代价
+func NewFromSource(source string) *Parser {
+	return NewFromSourceWithVersion(source, LatestVersion)
+}
+
+// NewFromSourceWithVersion 和NewWithVersion等价，但额外记住source，
+// 使返回的Parser可以调用ParseIncremental(见stream.go)。
+func NewFromSourceWithVersion(source string, v Version) *Parser {
+	p := NewWithVersion(lexer.New(source), v)
+	p.source = source
+	p.hasSource = true
 	return p
 }
 
+// registerDefaults 注册解析器内置的前缀/中缀解析函数和运算符优先级。
+// 这些条目之后可以被RegisterPrefix/RegisterInfix覆盖或补充。
+func (p *Parser) registerDefaults() {
+	p.prefixParseFns = make(map[lexer.TokenType]prefixParseFn)
+	p.infixParseFns = make(map[lexer.TokenType]infixParseFn)
+	p.precedences = make(map[lexer.TokenType]Precedence, len(precedenceMap))
+
+	for tok, prec := range precedenceMap {
+		p.precedences[tok] = prec
+	}
+
+	p.RegisterPrefix(lexer.T_VARIABLE, p.parseVariable)
+	p.RegisterPrefix(lexer.T_LNUMBER, p.parseIntegerLiteral)
+	p.RegisterPrefix(lexer.T_DNUMBER, p.parseFloatLiteral)
+	p.RegisterPrefix(lexer.T_CONSTANT_ENCAPSED_STRING, p.parseStringLiteral)
+	p.RegisterPrefix(lexer.T_STRING, p.parseStringOrKeywordLiteral)
+	p.RegisterPrefix(lexer.TOKEN_LPAREN, p.parseGroupedExpression)
+	p.RegisterPrefix(lexer.TOKEN_MINUS, p.parseUnaryExpression)
+	p.RegisterPrefix(lexer.TOKEN_PLUS, p.parseUnaryExpression)
+	p.RegisterPrefix(lexer.TOKEN_EXCLAMATION, p.parseUnaryExpression)
+	p.RegisterPrefix(lexer.TOKEN_TILDE, p.parseUnaryExpression)
+	p.RegisterPrefix(lexer.T_INC, p.parsePreIncrementDecrement)
+	p.RegisterPrefix(lexer.T_DEC, p.parsePreIncrementDecrement)
+	p.RegisterPrefix(lexer.T_CLONE, p.parseCloneExpression)
+	p.RegisterPrefix(lexer.T_NEW, p.parseNewExpression)
+	p.RegisterPrefix(lexer.T_ARRAY, p.parseArrayExpression)
+	p.RegisterPrefix(lexer.TOKEN_LBRACKET, p.parseArrayLiteral)
+	p.RegisterPrefix(lexer.T_FUNCTION, p.parseAnonymousFunction)
+	p.RegisterPrefix(lexer.T_FN, p.parseArrowFunction)
+	if p.version.AtLeast(PHP80) {
+		p.RegisterPrefix(lexer.T_MATCH, p.parseMatchExpression)
+	}
+	p.RegisterPrefix(lexer.T_THROW, p.parseThrowExpression)
+	p.RegisterPrefix(lexer.T_YIELD, p.parseYieldExpression)
+	p.RegisterPrefix(lexer.T_YIELD_FROM, p.parseYieldFromExpression)
+	p.RegisterPrefix(lexer.T_INCLUDE, p.parseIncludeExpression)
+	p.RegisterPrefix(lexer.T_INCLUDE_ONCE, p.parseIncludeExpression)
+	p.RegisterPrefix(lexer.T_REQUIRE, p.parseIncludeExpression)
+	p.RegisterPrefix(lexer.T_REQUIRE_ONCE, p.parseIncludeExpression)
+	p.RegisterPrefix(lexer.T_ISSET, p.parseIssetExpression)
+	p.RegisterPrefix(lexer.T_EMPTY, p.parseEmptyExpression)
+	p.RegisterPrefix(lexer.T_EVAL, p.parseEvalExpression)
+	p.RegisterPrefix(lexer.T_EXIT, p.parseExitExpression)
+	p.RegisterPrefix(lexer.T_PRINT, p.parsePrintExpression)
+	p.RegisterPrefix(lexer.T_LIST, p.parseListExpression)
+	p.RegisterPrefix(lexer.T_INT_CAST, p.parseCastExpression)
+	p.RegisterPrefix(lexer.T_DOUBLE_CAST, p.parseCastExpression)
+	p.RegisterPrefix(lexer.T_STRING_CAST, p.parseCastExpression)
+	p.RegisterPrefix(lexer.T_ARRAY_CAST, p.parseCastExpression)
+	p.RegisterPrefix(lexer.T_OBJECT_CAST, p.parseCastExpression)
+	p.RegisterPrefix(lexer.T_BOOL_CAST, p.parseCastExpression)
+	p.RegisterPrefix(lexer.T_UNSET_CAST, p.parseCastExpression)
+	p.RegisterPrefix(lexer.TOKEN_AT, p.parseErrorSuppressionExpression)
+	p.RegisterPrefix(lexer.T_LINE, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_FILE, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_DIR, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_CLASS_C, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_TRAIT_C, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_METHOD_C, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_FUNC_C, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_NS_C, p.parseMagicConstant)
+	p.RegisterPrefix(lexer.T_START_HEREDOC, p.parseHeredocExpression)
+
+	p.RegisterInfix(lexer.TOKEN_PLUS, p.parseBinaryExpression)
+	p.RegisterInfix(lexer.TOKEN_MINUS, p.parseBinaryExpression)
+	p.RegisterInfix(lexer.TOKEN_MULTIPLY, p.parseBinaryExpression)
+	p.RegisterInfix(lexer.TOKEN_DIVIDE, p.parseBinaryExpression)
+	p.RegisterInfix(lexer.TOKEN_MODULO, p.parseBinaryExpression)
+	p.RegisterInfix(lexer.T_POW, p.parseBinaryExpression)
+	p.RegisterInfix(lexer.TOKEN_DOT, p.parseConcatExpression)
+	p.RegisterInfix(lexer.TOKEN_LT, p.parseComparisonExpression)
+	p.RegisterInfix(lexer.TOKEN_GT, p.parseComparisonExpression)
+	p.RegisterInfix(lexer.T_IS_SMALLER_OR_EQUAL, p.parseComparisonExpression)
+	p.RegisterInfix(lexer.T_IS_GREATER_OR_EQUAL, p.parseComparisonExpression)
+	p.RegisterInfix(lexer.T_SPACESHIP, p.parseComparisonExpression)
+	p.RegisterInfix(lexer.T_IS_EQUAL, p.parseEqualityExpression)
+	p.RegisterInfix(lexer.T_IS_NOT_EQUAL, p.parseEqualityExpression)
+	p.RegisterInfix(lexer.T_IS_IDENTICAL, p.parseEqualityExpression)
+	p.RegisterInfix(lexer.T_IS_NOT_IDENTICAL, p.parseEqualityExpression)
+	p.RegisterInfix(lexer.TOKEN_AMPERSAND, p.parseBitwiseExpression)
+	p.RegisterInfix(lexer.TOKEN_PIPE, p.parseBitwiseExpression)
+	p.RegisterInfix(lexer.TOKEN_CARET, p.parseBitwiseExpression)
+	p.RegisterInfix(lexer.T_SL, p.parseBitwiseExpression)
+	p.RegisterInfix(lexer.T_SR, p.parseBitwiseExpression)
+	p.RegisterInfix(lexer.T_BOOLEAN_AND, p.parseLogicalExpression)
+	p.RegisterInfix(lexer.T_BOOLEAN_OR, p.parseLogicalExpression)
+	p.RegisterInfix(lexer.T_LOGICAL_AND, p.parseLogicalExpression)
+	p.RegisterInfix(lexer.T_LOGICAL_OR, p.parseLogicalExpression)
+	p.RegisterInfix(lexer.T_LOGICAL_XOR, p.parseLogicalExpression)
+	p.RegisterInfix(lexer.TOKEN_EQUAL, p.parseAssignmentExpression)
+	p.RegisterInfix(lexer.T_PLUS_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_MINUS_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_MUL_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_DIV_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_CONCAT_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_MOD_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_AND_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_OR_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_XOR_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_SL_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_SR_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_POW_EQUAL, p.parseCompoundAssignmentExpression)
+	p.RegisterInfix(lexer.T_COALESCE_EQUAL, p.parseCoalesceAssignmentExpression)
+	p.RegisterInfix(lexer.TOKEN_QUESTION, p.parseTernaryExpression)
+	p.RegisterInfix(lexer.T_COALESCE, p.parseCoalesceExpression)
+	p.RegisterInfix(lexer.T_INSTANCEOF, p.parseInstanceofExpression)
+	if p.version.AtLeast(PHP84) {
+		p.RegisterInfix(lexer.T_PIPE, p.parsePipeExpression)
+	}
+	p.RegisterInfix(lexer.TOKEN_LPAREN, p.parseCallExpression)
+	p.RegisterInfix(lexer.T_OBJECT_OPERATOR, p.parsePropertyAccessExpression)
+	if p.version.AtLeast(PHP80) {
+		p.RegisterInfix(lexer.T_NULLSAFE_OBJECT_OPERATOR, p.parseNullsafePropertyAccessExpression)
+	}
+	p.RegisterInfix(lexer.T_PAAMAYIM_NEKUDOTAYIM, p.parseStaticAccessExpression)
+	p.RegisterInfix(lexer.TOKEN_LBRACKET, p.parseArrayAccessExpression)
+	p.RegisterInfix(lexer.T_INC, p.parsePostIncrementDecrement)
+	p.RegisterInfix(lexer.T_DEC, p.parsePostIncrementDecrement)
+}
+
+// RegisterPrefix 为指定token类型注册前缀解析函数，覆盖已有条目(若存在)。
+// 这允许调用方在不修改解析器核心的情况下增加新的前缀产生式，
+// 例如特定PHP版本的实验性token。
+func (p *Parser) RegisterPrefix(tokenType lexer.TokenType, fn prefixParseFn) {
+	if p.prefixParseFns == nil {
+		p.prefixParseFns = make(map[lexer.TokenType]prefixParseFn)
+	}
+	p.prefixParseFns[tokenType] = fn
+}
+
+// RegisterInfix 为指定token类型注册中缀解析函数，并可选地设置其优先级。
+// 优先级沿用precedences表中已有的值(若该token之前未出现过则为LOWEST)，
+// 需要自定义优先级时请先调用SetPrecedence。
+func (p *Parser) RegisterInfix(tokenType lexer.TokenType, fn infixParseFn) {
+	if p.infixParseFns == nil {
+		p.infixParseFns = make(map[lexer.TokenType]infixParseFn)
+	}
+	p.infixParseFns[tokenType] = fn
+}
+
+// SetPrecedence 覆盖或新增指定token类型的运算符优先级，供注册自定义
+// 中缀运算符(如`|>`管道或自定义`@@`运算符)时使用。
+func (p *Parser) SetPrecedence(tokenType lexer.TokenType, precedence Precedence) {
+	if p.precedences == nil {
+		p.precedences = make(map[lexer.TokenType]Precedence)
+	}
+	p.precedences[tokenType] = precedence
+}
+
 // nextToken 推进token流
 func (p *Parser) nextToken() {
 	p.currentToken = p.peekToken
@@ -43,42 +339,57 @@ func (p *Parser) Errors() []error {
 	return p.errors
 }
 
-// addError 添加解析错误
+// addError 添加解析错误。保留这个简单签名是为了不必改动现有的
+// 调用点，内部统一转为带源码跨度的ParseError，详见addDiagnostic
 func (p *Parser) addError(msg string) {
-	pos := lexer.Position{
-		Line:   p.currentToken.Position.Line,
-		Column: p.currentToken.Position.Column,
-		Offset: p.currentToken.Position.Offset,
-	}
-	p.errors = append(p.errors, 
-		errors.NewSyntaxError(msg, pos))
+	pos := p.currentToken.Position
+	p.log().Errorf("parse error at %d:%d (offset %d): %s", pos.Line, pos.Column, pos.Offset, msg)
+	p.addDiagnostic(SeverityError, "", msg, "", nil)
 }
 
 // Parse 解析入口点 - 对应 start 规则
 func (p *Parser) Parse() ast.Node {
-	return p.parseTopStatementList()
+	p.log().Infof("parse started (version=%v)", p.version)
+	root := p.parseTopStatementList()
+	p.log().Infof("parse finished: %d diagnostic(s)", len(p.diagnostics))
+	return root
 }
 
-// parseTopStatementList 对应 top_statement_list 规则
+// parseTopStatementList 对应 top_statement_list 规则。每个顶层语句
+// 都在safeParseTopStatement中被defer/recover保护，因此单条语句的
+// panic(无论是尚未实现的产生式还是真正的解析错误)不会终止整个解析，
+// Errors()会在解析结束后返回本次解析收集到的全部诊断
 func (p *Parser) parseTopStatementList() ast.Node {
 	statements := []ast.Node{}
-	
+
 	for p.currentToken.Type != lexer.T_EOF {
-		stmt := p.parseTopStatement()
+		if p.errorLimitReached() {
+			break
+		}
+
+		stmt := p.safeParseTopStatement()
 		if stmt != nil {
 			statements = append(statements, stmt)
 		}
-		
-		// 如果解析出错，尝试恢复
-		if p.currentToken.Type == lexer.T_UNKNOWN {
-			p.nextToken()
-			continue
-		}
 	}
-	
+
 	return ast.NewStatementList(statements)
 }
 
+// safeParseTopStatement 在parseTopStatement外包一层panic恢复：
+// 捕获到panic时记录为解析错误，并跳转到下一个同步点继续解析
+func (p *Parser) safeParseTopStatement() (stmt ast.Node) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.addError(fmt.Sprintf("%v", r))
+			p.recover(defaultSyncTokens...)
+			stmt = nil
+		}
+	}()
+
+	return p.parseTopStatement()
+}
+
 // parseTopStatement 对应 top_statement 规则
 func (p *Parser) parseTopStatement() ast.Node {
 	switch p.currentToken.Type {
@@ -102,11 +413,19 @@ func (p *Parser) parseTopStatement() ast.Node {
 	case lexer.T_ATTRIBUTE:
 		return p.parseAttributedTopStatement()
 		
+	// enum声明是PHP 8.1引入的，在更早的目标版本下需要拒绝并给出提示
+	case lexer.T_ENUM:
+		if !p.requireVersion(PHP81, "enum declarations") {
+			p.recover(defaultSyncTokens...)
+			return nil
+		}
+		return p.parseAttributedTopStatement()
+
 	// attributed_top_statement (function, class, etc.)
-	case lexer.T_FUNCTION, lexer.T_CLASS, lexer.T_INTERFACE, 
-		 lexer.T_TRAIT, lexer.T_ENUM, lexer.T_ABSTRACT, lexer.T_FINAL:
+	case lexer.T_FUNCTION, lexer.T_CLASS, lexer.T_INTERFACE,
+		 lexer.T_TRAIT, lexer.T_ABSTRACT, lexer.T_FINAL:
 		return p.parseAttributedTopStatement()
-		
+
 	default:
 		// 普通语句
 		return p.parseStatement()
@@ -339,208 +658,59 @@ func (p *Parser) parseExpressionPrecedence(precedence Precedence) ast.Node {
 	return left
 }
 
-// parsePrefixExpression 解析前缀表达式
+// parsePrefixExpression 解析前缀表达式，查找当前token在
+// prefixParseFns注册表中的解析函数并调用
 func (p *Parser) parsePrefixExpression() ast.Node {
-	switch p.currentToken.Type {
-	case lexer.T_VARIABLE:
-		return p.parseVariable()
-		
-	case lexer.T_LNUMBER:
-		return p.parseIntegerLiteral()
-		
-	case lexer.T_DNUMBER:
-		return p.parseFloatLiteral()
-		
-	case lexer.T_CONSTANT_ENCAPSED_STRING:
-		return p.parseStringLiteral()
-		
-	case lexer.T_STRING:
-		// 检查是否为true/false/null关键字
-		if strings.ToLower(p.currentToken.Value) == "true" || 
-		   strings.ToLower(p.currentToken.Value) == "false" {
-			return p.parseBooleanLiteral()
-		}
-		if strings.ToLower(p.currentToken.Value) == "null" {
-			return p.parseNullLiteral()
-		}
-		return p.parseIdentifier()
-		
-	case lexer.TOKEN_LPAREN:
-		return p.parseGroupedExpression()
-		
-	case lexer.TOKEN_MINUS, lexer.TOKEN_PLUS:
-		return p.parseUnaryExpression()
-		
-	case lexer.TOKEN_EXCLAMATION, lexer.TOKEN_TILDE:
-		return p.parseUnaryExpression()
-		
-	case lexer.T_INC, lexer.T_DEC:
-		return p.parsePreIncrementDecrement()
-		
-	case lexer.T_CLONE:
-		return p.parseCloneExpression()
-		
-	case lexer.T_NEW:
-		return p.parseNewExpression()
-		
-	case lexer.T_ARRAY:
-		return p.parseArrayExpression()
-		
-	case lexer.TOKEN_LBRACKET:
-		return p.parseArrayLiteral()
-		
-	case lexer.T_FUNCTION:
-		return p.parseAnonymousFunction()
-		
-	case lexer.T_FN:
-		return p.parseArrowFunction()
-		
-	case lexer.T_MATCH:
-		return p.parseMatchExpression()
-		
-	case lexer.T_THROW:
-		return p.parseThrowExpression()
-		
-	case lexer.T_YIELD:
-		return p.parseYieldExpression()
-		
-	case lexer.T_YIELD_FROM:
-		return p.parseYieldFromExpression()
-		
-	case lexer.T_INCLUDE, lexer.T_INCLUDE_ONCE, lexer.T_REQUIRE, lexer.T_REQUIRE_ONCE:
-		return p.parseIncludeExpression()
-		
-	case lexer.T_ISSET:
-		return p.parseIssetExpression()
-		
-	case lexer.T_EMPTY:
-		return p.parseEmptyExpression()
-		
-	case lexer.T_EVAL:
-		return p.parseEvalExpression()
-		
-	case lexer.T_EXIT:
-		return p.parseExitExpression()
-		
-	case lexer.T_PRINT:
-		return p.parsePrintExpression()
-		
-	case lexer.T_LIST:
-		return p.parseListExpression()
-		
-	// 类型转换
-	case lexer.T_INT_CAST, lexer.T_DOUBLE_CAST, lexer.T_STRING_CAST,
-		 lexer.T_ARRAY_CAST, lexer.T_OBJECT_CAST, lexer.T_BOOL_CAST, lexer.T_UNSET_CAST:
-		return p.parseCastExpression()
-		
-	// @ 错误抑制
-	case lexer.TOKEN_AT:
-		return p.parseErrorSuppressionExpression()
-		
-	// 魔术常量
-	case lexer.T_LINE, lexer.T_FILE, lexer.T_DIR, lexer.T_CLASS_C,
-		 lexer.T_TRAIT_C, lexer.T_METHOD_C, lexer.T_FUNC_C, lexer.T_NS_C:
-		return p.parseMagicConstant()
-		
-	// heredoc/nowdoc
-	case lexer.T_START_HEREDOC:
-		return p.parseHeredocExpression()
-		
-		
-	default:
-		p.addError(fmt.Sprintf("no prefix parse function for %s found", p.currentToken.Type))
+	fn, ok := p.prefixParseFns[p.currentToken.Type]
+	if !ok {
+		p.reportUnregisteredToken(p.currentToken.Type, "prefix")
 		return nil
 	}
+	return fn()
 }
 
-// parseInfixExpression 解析中缀表达式
+// parseInfixExpression 解析中缀表达式，查找当前token在
+// infixParseFns注册表中的解析函数并调用
 func (p *Parser) parseInfixExpression(left ast.Node) ast.Node {
-	switch p.currentToken.Type {
-	// 二元运算符
-	case lexer.TOKEN_PLUS, lexer.TOKEN_MINUS, lexer.TOKEN_MULTIPLY,
-		 lexer.TOKEN_DIVIDE, lexer.TOKEN_MODULO, lexer.T_POW:
-		return p.parseBinaryExpression(left)
-		
-	case lexer.TOKEN_DOT:
-		return p.parseConcatExpression(left)
-		
-	case lexer.TOKEN_LT, lexer.TOKEN_GT, lexer.T_IS_SMALLER_OR_EQUAL,
-		 lexer.T_IS_GREATER_OR_EQUAL, lexer.T_SPACESHIP:
-		return p.parseComparisonExpression(left)
-		
-	case lexer.T_IS_EQUAL, lexer.T_IS_NOT_EQUAL,
-		 lexer.T_IS_IDENTICAL, lexer.T_IS_NOT_IDENTICAL:
-		return p.parseEqualityExpression(left)
-		
-	case lexer.TOKEN_AMPERSAND, lexer.TOKEN_PIPE, lexer.TOKEN_CARET,
-		 lexer.T_SL, lexer.T_SR:
-		return p.parseBitwiseExpression(left)
-		
-	case lexer.T_BOOLEAN_AND, lexer.T_BOOLEAN_OR,
-		 lexer.T_LOGICAL_AND, lexer.T_LOGICAL_OR, lexer.T_LOGICAL_XOR:
-		return p.parseLogicalExpression(left)
-		
-	// 赋值运算符
-	case lexer.TOKEN_EQUAL:
-		return p.parseAssignmentExpression(left)
-		
-	case lexer.T_PLUS_EQUAL, lexer.T_MINUS_EQUAL, lexer.T_MUL_EQUAL,
-		 lexer.T_DIV_EQUAL, lexer.T_CONCAT_EQUAL, lexer.T_MOD_EQUAL,
-		 lexer.T_AND_EQUAL, lexer.T_OR_EQUAL, lexer.T_XOR_EQUAL,
-		 lexer.T_SL_EQUAL, lexer.T_SR_EQUAL, lexer.T_POW_EQUAL:
-		return p.parseCompoundAssignmentExpression(left)
-		
-	case lexer.T_COALESCE_EQUAL:
-		return p.parseCoalesceAssignmentExpression(left)
-		
-	// 三元运算符
-	case lexer.TOKEN_QUESTION:
-		return p.parseTernaryExpression(left)
-		
-	// 空合并运算符
-	case lexer.T_COALESCE:
-		return p.parseCoalesceExpression(left)
-		
-	// instanceof
-	case lexer.T_INSTANCEOF:
-		return p.parseInstanceofExpression(left)
-		
-	// 管道运算符 (PHP 8.4)
-	case lexer.T_PIPE:
-		return p.parsePipeExpression(left)
-		
-	// 函数/方法调用
-	case lexer.TOKEN_LPAREN:
-		return p.parseCallExpression(left)
-		
-	// 属性访问
-	case lexer.T_OBJECT_OPERATOR:
-		return p.parsePropertyAccessExpression(left)
-		
-	case lexer.T_NULLSAFE_OBJECT_OPERATOR:
-		return p.parseNullsafePropertyAccessExpression(left)
-		
-	// 静态访问
-	case lexer.T_PAAMAYIM_NEKUDOTAYIM:
-		return p.parseStaticAccessExpression(left)
-		
-	// 数组访问
-	case lexer.TOKEN_LBRACKET:
-		return p.parseArrayAccessExpression(left)
-		
-	// 后缀自增/自减
-	case lexer.T_INC, lexer.T_DEC:
-		return p.parsePostIncrementDecrement(left)
-		
-	default:
-		p.addError(fmt.Sprintf("no infix parse function for %s found", p.currentToken.Type))
+	fn, ok := p.infixParseFns[p.currentToken.Type]
+	if !ok {
+		p.reportUnregisteredToken(p.currentToken.Type, "infix")
 		return left
 	}
+	return fn(left)
+}
+
+// reportUnregisteredToken 报告一个没有注册解析函数的token。如果该
+// token实际上是被版本门控排除的(例如目标版本是7.4时遇到了match)，
+// 提示信息会说明所需的最低PHP版本，而不是单纯的"无法识别"
+func (p *Parser) reportUnregisteredToken(tok lexer.TokenType, kind string) {
+	msg := fmt.Sprintf("no %s parse function for %s found", kind, tok)
+	if min, gated := featureVersions[tok]; gated && !p.version.AtLeast(min) {
+		p.addDiagnostic(SeverityError, "", msg,
+			fmt.Sprintf("%s requires PHP >= %s, but this file is being parsed as PHP %s", tok, min, p.version),
+			nil)
+		return
+	}
+	p.addDiagnostic(SeverityError, "", msg, "", nil)
+}
+
+// parseStringOrKeywordLiteral T_STRING可能是true/false/null字面量，
+// 也可能是普通标识符，在此统一分派
+func (p *Parser) parseStringOrKeywordLiteral() ast.Node {
+	switch strings.ToLower(p.currentToken.Value) {
+	case "true", "false":
+		return p.parseBooleanLiteral()
+	case "null":
+		return p.parseNullLiteral()
+	default:
+		return p.parseIdentifier()
+	}
 }
 
-// peekPrecedence 获取下一个token的优先级
+// peekPrecedence 获取下一个token的优先级，优先查找本实例的
+// precedences表，这样用户注册的自定义优先级也能生效
 func (p *Parser) peekPrecedence() Precedence {
-	if precedence, ok := precedenceMap[p.peekToken.Type]; ok {
+	if precedence, ok := p.precedences[p.peekToken.Type]; ok {
 		return precedence
 	}
 	return LOWEST
@@ -548,7 +718,7 @@ func (p *Parser) peekPrecedence() Precedence {
 
 // currentPrecedence 获取当前token的优先级
 func (p *Parser) currentPrecedence() Precedence {
-	if precedence, ok := precedenceMap[p.currentToken.Type]; ok {
+	if precedence, ok := p.precedences[p.currentToken.Type]; ok {
 		return precedence
 	}
 	return LOWEST
@@ -561,7 +731,10 @@ func (p *Parser) expectToken(tokenType lexer.TokenType) bool {
 		return true
 	}
 	
-	p.addError(fmt.Sprintf("expected %s, got %s", tokenType, p.peekToken.Type))
+	p.addDiagnostic(SeverityError, "",
+		fmt.Sprintf("expected %s, got %s", tokenType, p.peekToken.Type),
+		fmt.Sprintf("insert a %s here", tokenType),
+		[]string{tokenType.String()})
 	return false
 }
 
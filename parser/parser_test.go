@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/wudi/php-parser/ast"
+	"github.com/wudi/php-parser/lexer"
+)
+
+// TestRegisterPrefixOverridesDefault 验证RegisterPrefix可以覆盖内置的
+// 前缀解析函数，而不需要修改parsePrefixExpression本身
+func TestRegisterPrefixOverridesDefault(t *testing.T) {
+	p := &Parser{errors: []error{}}
+	p.registerDefaults()
+
+	called := false
+	p.RegisterPrefix(lexer.T_LNUMBER, func() ast.Node {
+		called = true
+		return ast.NewIntegerLiteral(99, ast.Position{})
+	})
+
+	fn, ok := p.prefixParseFns[lexer.T_LNUMBER]
+	if !ok {
+		t.Fatal("expected a prefix parse function to be registered for T_LNUMBER")
+	}
+
+	node := fn()
+	if !called {
+		t.Fatal("expected the overriding prefix parse function to be invoked")
+	}
+	if zval, ok := node.(*ast.ZvalNode); !ok || zval.Value != int64(99) {
+		t.Fatalf("expected overriding function's result, got %v", node)
+	}
+}
+
+// TestRegisterInfixCustomPrecedence 模拟注册一个自定义的中缀运算符
+// (例如`|>`管道)并赋予自定义优先级，验证peekPrecedence能读取到它
+func TestRegisterInfixCustomPrecedence(t *testing.T) {
+	p := &Parser{errors: []error{}}
+	p.registerDefaults()
+
+	const customPipePrecedence = CONCAT + 1
+
+	p.RegisterInfix(lexer.T_PIPE, func(left ast.Node) ast.Node {
+		return ast.NewBinaryNode(ast.AST_BINARY_OP, "|>", left, nil, ast.Position{})
+	})
+	p.SetPrecedence(lexer.T_PIPE, customPipePrecedence)
+
+	if _, ok := p.infixParseFns[lexer.T_PIPE]; !ok {
+		t.Fatal("expected an infix parse function to be registered for T_PIPE")
+	}
+
+	p.peekToken = lexer.Token{Type: lexer.T_PIPE}
+	if got := p.peekPrecedence(); got != customPipePrecedence {
+		t.Errorf("expected custom precedence %d, got %d", customPipePrecedence, got)
+	}
+}
@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"github.com/wudi/php-parser/ast"
+	"github.com/wudi/php-parser/lexer"
+)
+
+// ParseStream 逐条语句地解析输入，通过channel以流式方式产出顶层
+// 语句，而不是像Parse()那样等整个文件解析完才返回一整棵树。
+// 解析在一个goroutine中进行，channel关闭即表示解析结束(可通过
+// Errors()/Diagnostics()获取解析期间产生的诊断)。这使得LSP风格的
+// 编辑器或分析管线可以在文件仍在被lex的同时就开始处理前面的语句。
+func (p *Parser) ParseStream() <-chan ast.Node {
+	out := make(chan ast.Node)
+
+	go func() {
+		defer close(out)
+
+		for p.currentToken.Type != lexer.T_EOF {
+			if p.errorLimitReached() {
+				return
+			}
+
+			stmt := p.safeParseTopStatement()
+			if stmt != nil {
+				out <- stmt
+			}
+		}
+	}()
+
+	return out
+}
+
+// Edit 描述一次源码编辑：区间[StartOffset, EndOffset)被NewText替换，
+// 偏移量以字节为单位，与ast.Position.Offset使用相同的坐标系
+type Edit struct {
+	StartOffset int
+	EndOffset   int
+	NewText     string
+}
+
+// statementSpan 返回prev中第i个顶层语句的[start, end)偏移区间。
+// 当前AST节点只记录起始Position，因此用下一条语句的起始偏移(或
+// 文件末尾)近似作为上一条语句的结束偏移；这对判断一次编辑落在
+// 哪些语句内部已经足够精确
+func statementSpan(stmts []ast.Node, i int, fileEnd int) (start, end int) {
+	start = stmts[i].GetPosition().Offset
+	if i+1 < len(stmts) {
+		end = stmts[i+1].GetPosition().Offset
+	} else {
+		end = fileEnd
+	}
+	return start, end
+}
+
+// ParseIncremental 在prev(此前p.Parse()产出的顶层语句列表，p必须是
+// 经由NewFromSource/NewFromSourceWithVersion构造、因此记得自己源码的
+// Parser)的基础上应用一次编辑，只重新解析与编辑区间重叠的语句，其余
+// 语句节点原样复用。p.source与edit共同决定编辑之后的完整源码，不需要
+// 调用方另行传入。
+//
+// 这是一个面向"单条语句重解析"场景的近似实现：由于节点目前只携带
+// 起始偏移量(见statementSpan)，一次编辑如果跨越了多条语句的边界，
+// 会保守地重新解析从第一条受影响语句开始直到文件末尾的全部内容，
+// 而不是尝试做更细粒度的树内拼接。
+//
+// ParseIncremental本身不会修改p.source：它返回的是编辑之后的新树，
+// 调用方必须用NewFromSourceWithVersion(newSource, p.version)构造下一个
+// Parser才能在这棵新树上继续增量编辑，就像第一次Parse()之前那样——
+// p本身只认p.source这一份源码，同一个p上调用第二次ParseIncremental
+// 会用p.source(没有应用上一次编辑)而不是上一次编辑的结果去推算
+// newSource，产生的树会和prev的偏移量对不上却不会报错，所以这里直接
+// 用incrementalUsed把"同一个p只能ParseIncremental一次"这件事从文档
+// 要求变成运行时检查。p.hasSource为false(即p不是经由
+// NewFromSource/NewFromSourceWithVersion构造)时，ParseIncremental
+// 没有办法推算编辑前后的源码，同样会panic提示调用方用错了构造函数。
+func (p *Parser) ParseIncremental(prev ast.Node, edit Edit) ast.Node {
+	if !p.hasSource {
+		panic("ParseIncremental: p was not built with NewFromSource/NewFromSourceWithVersion, so it has no source text to apply edit against")
+	}
+	if p.incrementalUsed {
+		panic("ParseIncremental: p already applied one edit; construct a fresh Parser via NewFromSourceWithVersion(newSource, p.version) to apply another")
+	}
+	p.incrementalUsed = true
+
+	newSource := p.source[:edit.StartOffset] + edit.NewText + p.source[edit.EndOffset:]
+	newParser := func(src string) *Parser {
+		return NewFromSourceWithVersion(src, p.version)
+	}
+
+	list, ok := prev.(*ast.ListNode)
+	if !ok || len(list.Elements) == 0 {
+		return newParser(newSource).Parse()
+	}
+
+	fileEnd := len(newSource)
+	firstAffected := -1
+	for i := range list.Elements {
+		start, end := statementSpan(list.Elements, i, fileEnd)
+		if edit.StartOffset < end && edit.EndOffset >= start {
+			firstAffected = i
+			break
+		}
+	}
+
+	if firstAffected == -1 {
+		// 编辑落在了已知语句范围之外(例如追加到文件末尾)，只需要
+		// 解析新增的尾部并拼接到既有语句列表后面
+		tailStart, _ := statementSpan(list.Elements, len(list.Elements)-1, fileEnd)
+		tail := newParser(newSource[tailStart:]).Parse()
+		tailList, ok := tail.(*ast.ListNode)
+		if !ok {
+			return prev
+		}
+		merged := append(append([]ast.Node{}, list.Elements...), tailList.Elements...)
+		return ast.NewStatementList(merged)
+	}
+
+	reparsed := newParser(newSource[list.Elements[firstAffected].GetPosition().Offset:]).Parse()
+	reparsedList, ok := reparsed.(*ast.ListNode)
+	if !ok {
+		return reparsed
+	}
+
+	merged := append(append([]ast.Node{}, list.Elements[:firstAffected]...), reparsedList.Elements...)
+	return ast.NewStatementList(merged)
+}
@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wudi/php-parser/lexer"
+)
+
+// bigPHPSource 生成一个由大量独立语句组成的合成PHP源码，用来近似
+// 一个~20k行的大文件，比较ParseStream与Parse()在内存表现上的差异。
+func bigPHPSource(statements int) string {
+	var b strings.Builder
+	for i := 0; i < statements; i++ {
+		b.WriteString("$x")
+		b.WriteString(strings.Repeat("a", 0))
+		b.WriteString(" = 1;\n")
+	}
+	return b.String()
+}
+
+func newBenchParser(src string) *Parser {
+	return New(lexer.New(src))
+}
+
+// BenchmarkParseAllAtOnce 测量一次性解析整份源码、物化全部AST节点
+// 的分配量(allocs/op、B/op)，作为ParseStream的对照组。这两个是
+// testing.B的标准累计分配计数器，统计"总共分配了多少次/多少字节"，
+// 和GC能不能回收、同一时刻有多少节点活着无关——这份源码不管是一次性
+// 解析还是流式消费，产生的节点总数是一样的，所以这两个benchmark的
+// allocs/op、B/op大概率非常接近，不足以说明ParseStream省内存。真正
+// 量化峰值常驻内存差异的是下面的BenchmarkParseAllAtOncePeakHeap/
+// BenchmarkParseStreamPeakHeap
+func BenchmarkParseAllAtOnce(b *testing.B) {
+	src := bigPHPSource(20000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newBenchParser(src).Parse()
+	}
+}
+
+// BenchmarkParseStream 测量以流式方式消费顶层语句的分配量；和
+// BenchmarkParseAllAtOnce的说明一样，这里的allocs/op、B/op不是用来
+// 展示内存节省的
+func BenchmarkParseStream(b *testing.B) {
+	src := bigPHPSource(20000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := newBenchParser(src)
+		for range p.ParseStream() {
+			// 立即丢弃，模拟边lex边处理、不保留整棵树的消费者
+		}
+	}
+}
+
+// peakHeapBytes运行fn，期间用一个后台goroutine每隔一小段时间采样
+// runtime.MemStats.HeapAlloc，返回观察到的最大值相对于fn开始前(做过
+// 一次runtime.GC()之后)的增量。这是在粗粒度上比较"一次性解析、同时
+// 物化全部节点"和"边产出边丢弃"两种用法峰值常驻内存差异的办法——
+// 采样依赖GC调度时机，存在噪声，只适合看数量级差异，不是逐字节精确
+// 的测量
+func peakHeapBytes(fn func()) uint64 {
+	runtime.GC()
+	var base runtime.MemStats
+	runtime.ReadMemStats(&base)
+
+	var peak uint64
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var m runtime.MemStats
+		ticker := time.NewTicker(200 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&m)
+				if m.HeapAlloc > peak {
+					peak = m.HeapAlloc
+				}
+			}
+		}
+	}()
+
+	fn()
+
+	close(stop)
+	<-done
+
+	if peak < base.HeapAlloc {
+		return 0
+	}
+	return peak - base.HeapAlloc
+}
+
+// BenchmarkParseAllAtOncePeakHeap采样"一次性Parse()整份源码、把全部
+// 顶层语句一起物化成一棵树"期间的堆内存峰值，和
+// BenchmarkParseStreamPeakHeap配对比较，这两个才是"ParseStream省
+// 内存"这个说法真正需要展示的数据
+func BenchmarkParseAllAtOncePeakHeap(b *testing.B) {
+	src := bigPHPSource(20000)
+	for i := 0; i < b.N; i++ {
+		peak := peakHeapBytes(func() {
+			newBenchParser(src).Parse()
+		})
+		b.ReportMetric(float64(peak), "peak-heap-bytes")
+	}
+}
+
+// BenchmarkParseStreamPeakHeap采样"以流式方式消费顶层语句、消费后
+// 立即丢弃"期间的堆内存峰值，和BenchmarkParseAllAtOncePeakHeap对照
+func BenchmarkParseStreamPeakHeap(b *testing.B) {
+	src := bigPHPSource(20000)
+	for i := 0; i < b.N; i++ {
+		peak := peakHeapBytes(func() {
+			p := newBenchParser(src)
+			for range p.ParseStream() {
+			}
+		})
+		b.ReportMetric(float64(peak), "peak-heap-bytes")
+	}
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/wudi/php-parser/lexer"
+)
+
+// Version 标识解析器应当接受的PHP语言版本，用于在Parser.registerDefaults
+// 中对按版本引入的语法(match、nullsafe、enum、管道运算符等)做门控
+type Version struct {
+	Major int
+	Minor int
+}
+
+// String 返回形如"8.1"的版本号
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast 判断v是否不低于other
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	return v.Minor >= other.Minor
+}
+
+// 预定义的PHP版本，覆盖本解析器关心的版本分界点
+var (
+	PHP56 = Version{Major: 5, Minor: 6}
+	PHP70 = Version{Major: 7, Minor: 0}
+	PHP74 = Version{Major: 7, Minor: 4}
+	PHP80 = Version{Major: 8, Minor: 0}
+	PHP81 = Version{Major: 8, Minor: 1}
+	PHP84 = Version{Major: 8, Minor: 4}
+)
+
+// LatestVersion 是New()使用的默认版本：接受本解析器支持的全部语法
+var LatestVersion = PHP84
+
+// featureVersions 记录每个按版本门控的token所需的最低PHP版本，
+// 用于给出"需要PHP >= X"而不是"无法识别的token"这样的提示
+//
+// readonly属性/构造函数属性提升(PHP >= 8.1)故意没有出现在这张表里：
+// 门控一个构造只有在解析器真的会碰到它时才有意义，而这个解析器目前
+// 还没有任何解析类成员修饰符(public/private/readonly/...)的代码——
+// parser.go的parseAttributedTopStatement还是个"panic(not implemented)"
+// 的占位实现，类/属性声明本身都还没有落地。等到属性/参数修饰符解析
+// 补上之后，应该在这里加上"lexer.T_READONLY: PHP81"，并在解析
+// readonly修饰符的地方像parseTopStatement对T_ENUM那样调用
+// requireVersion(PHP81, "readonly properties")。在那之前，提前登记
+// 一个没有任何调用点会检查的featureVersions条目只会造成"看起来已经
+// 支持"的假象，所以先不加。
+var featureVersions = map[lexer.TokenType]Version{
+	lexer.T_MATCH:                    PHP80,
+	lexer.T_NULLSAFE_OBJECT_OPERATOR: PHP80,
+	lexer.T_ENUM:                     PHP81,
+	lexer.T_PIPE:                     PHP84,
+}
+
+// requireVersion 检查当前解析器版本是否满足min，不满足时记录一条
+// 带提示的ParseError并返回false，调用方应据此跳过该构造的解析
+func (p *Parser) requireVersion(min Version, feature string) bool {
+	if p.version.AtLeast(min) {
+		return true
+	}
+	p.addDiagnostic(SeverityError, "", fmt.Sprintf("%s are not available in this PHP version", feature),
+		fmt.Sprintf("%s require PHP >= %s, but this file is being parsed as PHP %s", feature, min, p.version),
+		nil)
+	return false
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/wudi/php-parser/lexer"
+)
+
+// TestVersionGatesFeatureRegistration 验证同一份解析器在不同PHP版本下
+// 注册的前缀/中缀解析函数不同：match/nullsafe要求>=8.0，管道运算符
+// 要求>=8.4，这样低版本源码里出现这些token会得到"需要更高版本"的
+// 诊断而不是被默默接受
+func TestVersionGatesFeatureRegistration(t *testing.T) {
+	tests := []struct {
+		version       Version
+		wantMatch     bool
+		wantNullsafe  bool
+		wantPipe      bool
+	}{
+		{PHP56, false, false, false},
+		{PHP74, false, false, false},
+		{PHP80, true, true, false},
+		{PHP81, true, true, false},
+		{PHP84, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version.String(), func(t *testing.T) {
+			p := &Parser{errors: []error{}, version: tt.version}
+			p.registerDefaults()
+
+			if _, ok := p.prefixParseFns[lexer.T_MATCH]; ok != tt.wantMatch {
+				t.Errorf("T_MATCH registered = %v, want %v", ok, tt.wantMatch)
+			}
+			if _, ok := p.infixParseFns[lexer.T_NULLSAFE_OBJECT_OPERATOR]; ok != tt.wantNullsafe {
+				t.Errorf("T_NULLSAFE_OBJECT_OPERATOR registered = %v, want %v", ok, tt.wantNullsafe)
+			}
+			if _, ok := p.infixParseFns[lexer.T_PIPE]; ok != tt.wantPipe {
+				t.Errorf("T_PIPE registered = %v, want %v", ok, tt.wantPipe)
+			}
+		})
+	}
+}
+
+// TestRequireVersionGatesEnumDeclarations验证parseTopStatement对
+// T_ENUM的门控(>=8.1)：低于8.1时requireVersion应该拒绝并记录一条
+// 诊断，而不是静默接受或者直接走到还没实现的声明解析逻辑
+func TestRequireVersionGatesEnumDeclarations(t *testing.T) {
+	tests := []struct {
+		version   Version
+		wantAllow bool
+	}{
+		{PHP56, false},
+		{PHP80, false},
+		{PHP81, true},
+		{PHP84, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version.String(), func(t *testing.T) {
+			p := &Parser{version: tt.version}
+
+			got := p.requireVersion(PHP81, "enum declarations")
+			if got != tt.wantAllow {
+				t.Errorf("requireVersion(PHP81, ...) = %v, want %v", got, tt.wantAllow)
+			}
+			if !tt.wantAllow && len(p.diagnostics) == 0 {
+				t.Errorf("expected a diagnostic to be recorded when enum declarations are rejected")
+			}
+		})
+	}
+}
+
+// TestReadonlyHasNoFeatureVersionEntryYet记录了一个已知的、有意的
+// 缺口：readonly(PHP >= 8.1)是本系列请求里明确点名要做版本门控的五
+// 个构造之一，但这个解析器还没有任何解析类成员修饰符的代码可以挂
+// 门控检查，所以featureVersions里故意没有它，见version.go里
+// featureVersions上方的说明。这个测试只是把这个缺口钉在测试套件里，
+// 避免将来有人加了lexer.T_READONLY常量却忘了同时加门控和这条测试
+func TestReadonlyHasNoFeatureVersionEntryYet(t *testing.T) {
+	want := map[lexer.TokenType]Version{
+		lexer.T_MATCH:                    PHP80,
+		lexer.T_NULLSAFE_OBJECT_OPERATOR: PHP80,
+		lexer.T_ENUM:                     PHP81,
+		lexer.T_PIPE:                     PHP84,
+	}
+	if len(featureVersions) != len(want) {
+		t.Fatalf("featureVersions changed size (got %d entries, want %d); if a readonly entry was added, update this test and the comment in version.go that documented why it was absent", len(featureVersions), len(want))
+	}
+	for tok, version := range want {
+		if got, ok := featureVersions[tok]; !ok || got != version {
+			t.Errorf("featureVersions[%v] = %v, ok=%v; want %v", tok, got, ok, version)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	if !PHP81.AtLeast(PHP80) {
+		t.Error("expected 8.1 to be at least 8.0")
+	}
+	if PHP74.AtLeast(PHP80) {
+		t.Error("expected 7.4 to not be at least 8.0")
+	}
+	if !PHP80.AtLeast(PHP80) {
+		t.Error("expected a version to be at least itself")
+	}
+}